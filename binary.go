@@ -0,0 +1,152 @@
+package jtdinfer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// maxBinaryDepth is the maximum nesting of arrays, maps and tags when decoding
+// binary formats to not exhaust the stack on malicious input.
+const maxBinaryDepth = 10_000
+
+// binaryPreallocate is the maximum number of elements preallocated for arrays
+// and maps since the length is read from the untrusted input.
+const binaryPreallocate = 1024
+
+var (
+	errBinaryDepth   = errors.New("maximum nesting depth exceeded")
+	errBinaryMapKey  = errors.New("map key must be a string or an integer")
+	errBinaryInvalid = errors.New("invalid value")
+)
+
+// binaryReader is a reader for binary formats that keeps track of the offset
+// for error messages.
+type binaryReader struct {
+	r      *bufio.Reader
+	offset int64
+	depth  int
+}
+
+func newBinaryReader(r io.Reader) *binaryReader {
+	return &binaryReader{r: bufio.NewReader(r)}
+}
+
+func (b *binaryReader) readByte() (byte, error) {
+	c, err := b.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	b.offset++
+
+	return c, nil
+}
+
+// readN reads n bytes. The buffer grows with the data that is read to not
+// allocate the length read from the input up front.
+func (b *binaryReader) readN(n uint64) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if n <= binaryPreallocate {
+		buf.Grow(int(n))
+	}
+
+	read, err := io.CopyN(&buf, b.r, int64(min(n, uint64(1<<62))))
+	b.offset += read
+
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// readUint reads a big endian unsigned integer of n bytes.
+func (b *binaryReader) readUint(n int) (uint64, error) {
+	var v uint64
+
+	for i := 0; i < n; i++ {
+		c, err := b.readByte()
+		if err != nil {
+			return 0, unexpectedEOF(err)
+		}
+
+		v = v<<8 | uint64(c)
+	}
+
+	return v, nil
+}
+
+// enter increments the depth when decoding an array, map or tag.
+func (b *binaryReader) enter() error {
+	b.depth++
+	if b.depth > maxBinaryDepth {
+		return errBinaryDepth
+	}
+
+	return nil
+}
+
+func (b *binaryReader) leave() {
+	b.depth--
+}
+
+// mapKey converts a decoded map key to a string. Integer keys are formatted as
+// decimal strings.
+func mapKey(key any) (string, error) {
+	switch k := key.(type) {
+	case string:
+		return k, nil
+	case int64:
+		return strconv.FormatInt(k, 10), nil
+	case uint64:
+		return strconv.FormatUint(k, 10), nil
+	}
+
+	return "", fmt.Errorf("%w, got %T", errBinaryMapKey, key)
+}
+
+// unexpectedEOF converts EOF to an unexpected EOF since it's only valid to end
+// the stream between values.
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+// inferBinaryStream decodes values with the decoder until the end of the
+// stream and infers each value as a separate row.
+func inferBinaryStream(
+	ctx context.Context,
+	r *binaryReader,
+	format string,
+	hints Hints,
+	decode func() (any, error),
+) (*Inferrer, error) {
+	inferrer := NewInferrer(hints)
+
+	for {
+		if _, err := r.r.Peek(1); errors.Is(err, io.EOF) {
+			return inferrer, nil
+		}
+
+		offset := r.offset
+
+		value, err := decode()
+		if err != nil {
+			return inferrer, fmt.Errorf("jtdinfer: invalid %s at offset %d: %w", format, offset, err)
+		}
+
+		inferrer, err = inferrer.InferContext(ctx, value)
+		if err != nil {
+			return inferrer, err
+		}
+	}
+}
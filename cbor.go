@@ -0,0 +1,389 @@
+package jtdinfer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"time"
+)
+
+// CBOR major types.
+const (
+	cborUnsigned = iota
+	cborNegative
+	cborBytes
+	cborText
+	cborArray
+	cborMap
+	cborTag
+	cborSimple
+)
+
+// cborIndefinite is the additional information for indefinite length items and
+// the break code.
+const cborIndefinite = 31
+
+// errCBORBreak is returned when the break code is decoded where it's not ending
+// an indefinite length item.
+var errCBORBreak = fmt.Errorf("%w: unexpected break", errBinaryInvalid)
+
+// InferCBOR will decode all values in the CBOR sequence and infer each value as
+// a separate row. See `InferCBORContext` for details.
+func InferCBOR(r io.Reader, hints Hints) (*Inferrer, error) {
+	return InferCBORContext(context.Background(), r, hints)
+}
+
+// InferCBORContext will decode all values in the CBOR sequence and infer each
+// value as a separate row. Byte strings are inferred as strings with the format
+// `bytes` in the metadata, date/time (0) and epoch (1) tags as timestamps,
+// bignums (2 and 3) as numbers and integer map keys are converted to strings.
+// Other tags are inferred as the tagged value.
+func InferCBORContext(ctx context.Context, r io.Reader, hints Hints) (*Inferrer, error) {
+	d := &cborDecoder{newBinaryReader(r)}
+	return inferBinaryStream(ctx, d.binaryReader, "CBOR", hints, d.decode)
+}
+
+type cborDecoder struct {
+	*binaryReader
+}
+
+// decode decodes the next value.
+func (d *cborDecoder) decode() (any, error) {
+	c, err := d.readByte()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	major, info := c>>5, c&0x1f
+
+	if major == cborSimple {
+		return d.decodeSimple(info)
+	}
+
+	if info == cborIndefinite {
+		switch major {
+		case cborBytes, cborText:
+			return d.decodeIndefiniteString(major)
+		case cborArray:
+			return d.decodeArray(0, true)
+		case cborMap:
+			return d.decodeMap(0, true)
+		}
+
+		return nil, fmt.Errorf("%w: indefinite length for major type %d", errBinaryInvalid, major)
+	}
+
+	arg, err := d.argument(info)
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUnsigned:
+		return arg, nil
+	case cborNegative:
+		if arg <= math.MaxInt64 {
+			return -1 - int64(arg), nil
+		}
+
+		n := new(big.Int).SetUint64(arg)
+
+		return n.Neg(n).Sub(n, big.NewInt(1)), nil
+	case cborBytes:
+		return d.readN(arg)
+	case cborText:
+		b, err := d.readN(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		return string(b), nil
+	case cborArray:
+		return d.decodeArray(arg, false)
+	case cborMap:
+		return d.decodeMap(arg, false)
+	}
+
+	return d.decodeTag(arg)
+}
+
+// argument reads the argument for the additional information.
+func (d *cborDecoder) argument(info byte) (uint64, error) {
+	switch {
+	case info < 24:
+		return uint64(info), nil
+	case info <= 27:
+		return d.readUint(1 << (info - 24))
+	}
+
+	return 0, fmt.Errorf("%w: additional information %d", errBinaryInvalid, info)
+}
+
+func (d *cborDecoder) decodeSimple(info byte) (any, error) {
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		return nil, nil
+	case 25:
+		v, err := d.readUint(2)
+		if err != nil {
+			return nil, err
+		}
+
+		return halfFloat(uint16(v)), nil
+	case 26:
+		v, err := d.readUint(4)
+		if err != nil {
+			return nil, err
+		}
+
+		return float64(math.Float32frombits(uint32(v))), nil
+	case 27:
+		v, err := d.readUint(8)
+		if err != nil {
+			return nil, err
+		}
+
+		return math.Float64frombits(v), nil
+	case cborIndefinite:
+		return nil, errCBORBreak
+	}
+
+	return nil, fmt.Errorf("%w: simple value %d", errBinaryInvalid, info)
+}
+
+// decodeIndefiniteString concatenates the definite length chunks until the
+// break code.
+func (d *cborDecoder) decodeIndefiniteString(major byte) (any, error) {
+	var data []byte
+
+	for {
+		c, err := d.readByte()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+
+		if c == cborSimple<<5|cborIndefinite {
+			break
+		}
+
+		if c>>5 != major || c&0x1f == cborIndefinite {
+			return nil, fmt.Errorf("%w: invalid chunk in indefinite length string", errBinaryInvalid)
+		}
+
+		n, err := d.argument(c & 0x1f)
+		if err != nil {
+			return nil, err
+		}
+
+		chunk, err := d.readN(n)
+		if err != nil {
+			return nil, err
+		}
+
+		data = append(data, chunk...)
+	}
+
+	if major == cborText {
+		return string(data), nil
+	}
+
+	if data == nil {
+		data = []byte{}
+	}
+
+	return data, nil
+}
+
+// isBreak consumes the next byte if it's the break code.
+func (d *cborDecoder) isBreak() (bool, error) {
+	next, err := d.r.Peek(1)
+	if err != nil {
+		return false, unexpectedEOF(err)
+	}
+
+	if next[0] != cborSimple<<5|cborIndefinite {
+		return false, nil
+	}
+
+	_, err = d.readByte()
+
+	return true, err
+}
+
+// decodeArray decodes n values or, if indefinite, values until the break code.
+func (d *cborDecoder) decodeArray(n uint64, indefinite bool) (any, error) {
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+
+	defer d.leave()
+
+	values := make([]any, 0, min(n, binaryPreallocate))
+
+	for i := uint64(0); indefinite || i < n; i++ {
+		if indefinite {
+			done, err := d.isBreak()
+			if err != nil {
+				return nil, err
+			}
+
+			if done {
+				break
+			}
+		}
+
+		v, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, v)
+	}
+
+	return values, nil
+}
+
+// decodeMap decodes n pairs or, if indefinite, pairs until the break code.
+func (d *cborDecoder) decodeMap(n uint64, indefinite bool) (any, error) {
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+
+	defer d.leave()
+
+	m := make(map[string]any, min(n, binaryPreallocate))
+
+	for i := uint64(0); indefinite || i < n; i++ {
+		if indefinite {
+			done, err := d.isBreak()
+			if err != nil {
+				return nil, err
+			}
+
+			if done {
+				break
+			}
+		}
+
+		k, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+
+		if bigKey, ok := k.(*big.Int); ok && bigKey.IsInt64() {
+			k = bigKey.Int64()
+		}
+
+		key, err := mapKey(k)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+
+		m[key] = v
+	}
+
+	return m, nil
+}
+
+// decodeTag decodes the tagged value and converts it for known tags.
+func (d *cborDecoder) decodeTag(tag uint64) (any, error) {
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+
+	defer d.leave()
+
+	v, err := d.decode()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tag {
+	case 0:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: date/time tag must be a string", errBinaryInvalid)
+		}
+
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errBinaryInvalid, err)
+		}
+
+		return t, nil
+	case 1:
+		// The seconds must fit in an int64 to not overflow.
+		switch n := v.(type) {
+		case uint64:
+			if n > math.MaxInt64 {
+				return nil, fmt.Errorf("%w: epoch %d out of range", errBinaryInvalid, n)
+			}
+
+			return time.Unix(int64(n), 0).UTC(), nil
+		case int64:
+			return time.Unix(n, 0).UTC(), nil
+		case float64:
+			if math.IsNaN(n) || n < math.MinInt64 || n >= math.MaxInt64 {
+				return nil, fmt.Errorf("%w: epoch %v out of range", errBinaryInvalid, n)
+			}
+
+			sec, frac := math.Modf(n)
+
+			return time.Unix(int64(sec), int64(frac*1e9)).UTC(), nil
+		}
+
+		return nil, fmt.Errorf("%w: epoch tag must be a number", errBinaryInvalid)
+	case 2, 3:
+		b, ok := v.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("%w: bignum tag must be a byte string", errBinaryInvalid)
+		}
+
+		n := new(big.Int).SetBytes(b)
+		if tag == 3 {
+			n.Neg(n).Sub(n, big.NewInt(1))
+		}
+
+		return n, nil
+	}
+
+	return v, nil
+}
+
+// halfFloat converts an IEEE 754 half precision float to a float64.
+func halfFloat(h uint16) float64 {
+	exp := (h >> 10) & 0x1f
+	mant := float64(h & 0x3ff)
+
+	var v float64
+
+	switch exp {
+	case 0:
+		v = math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant == 0 {
+			v = math.Inf(1)
+		} else {
+			v = math.NaN()
+		}
+	default:
+		v = math.Ldexp(mant+1024, int(exp)-25)
+	}
+
+	if h&0x8000 != 0 {
+		return -v
+	}
+
+	return v
+}
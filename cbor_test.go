@@ -0,0 +1,139 @@
+package jtdinfer
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+
+	jtd "github.com/jsontypedef/json-typedef-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInferCBOR(t *testing.T) {
+	stream := []byte{
+		// {"id": 1, "data": h'0102', "at": 1(1), 1: "one", "n": -5}
+		0xa5,
+		0x62, 'i', 'd', 0x01,
+		0x64, 'd', 'a', 't', 'a', 0x42, 0x01, 0x02,
+		0x62, 'a', 't', 0xc1, 0x01,
+		0x01, 0x63, 'o', 'n', 'e',
+		0x61, 'n', 0x24,
+		// {_ "id": 256, "data": (_ h'01', h'02'), "at": 0("2023-01-01T00:00:00Z"), 1: null, "n": 1.5}
+		0xbf,
+		0x62, 'i', 'd', 0x19, 0x01, 0x00,
+		0x64, 'd', 'a', 't', 'a', 0x5f, 0x41, 0x01, 0x41, 0x02, 0xff,
+		0x62, 'a', 't', 0xc0, 0x74,
+		'2', '0', '2', '3', '-', '0', '1', '-', '0', '1', 'T', '0', '0', ':', '0', '0', ':', '0', '0', 'Z',
+		0x01, 0xf6,
+		0x61, 'n', 0xf9, 0x3e, 0x00,
+		0xff,
+	}
+
+	inferrer, err := InferCBOR(bytes.NewReader(stream), WithoutHints())
+	require.NoError(t, err)
+
+	assert.Equal(t, Schema{
+		Properties: map[string]Schema{
+			"id": {Type: jtd.TypeUint16},
+			"data": {
+				Type:     jtd.TypeString,
+				Metadata: map[string]any{"format": MetadataFormatBytes},
+			},
+			"at": {Type: jtd.TypeTimestamp},
+			"1":  {Type: jtd.TypeString, Nullable: true},
+			"n":  {Type: jtd.TypeFloat64},
+		},
+	}, inferrer.IntoSchema())
+}
+
+func TestInferCBORBigNum(t *testing.T) {
+	// [2(h'010000000000000000'), 3(h'00'), -2^64]
+	stream := []byte{
+		0x83,
+		0xc2, 0x49, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0xc3, 0x41, 0x00,
+		0x3b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	}
+
+	inferrer, err := InferCBOR(bytes.NewReader(stream), Hints{BigInt: BigIntAsString})
+	require.NoError(t, err)
+
+	assert.Equal(t, Schema{
+		Elements: &Schema{
+			Type:     jtd.TypeString,
+			Metadata: map[string]any{"format": "bigint"},
+		},
+	}, inferrer.IntoSchema())
+}
+
+func TestHalfFloat(t *testing.T) {
+	for h, expected := range map[uint16]float64{
+		0x0000: 0,
+		0x3c00: 1,
+		0x3e00: 1.5,
+		0xc000: -2,
+		0x7bff: 65504,
+		0x0001: 5.960464477539063e-08,
+		0x7c00: math.Inf(1),
+	} {
+		assert.Equal(t, expected, halfFloat(h), "%#04x", h)
+	}
+
+	assert.True(t, math.IsNaN(halfFloat(0x7e00)))
+}
+
+func TestInferCBORErrors(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		stream      []byte
+		err         error
+	}{
+		{
+			description: "truncated",
+			stream:      []byte{0x82, 0x01},
+			err:         io.ErrUnexpectedEOF,
+		},
+		{
+			description: "unexpected break",
+			stream:      []byte{0x82, 0x01, 0xff},
+			err:         errCBORBreak,
+		},
+		{
+			description: "break in nested definite array",
+			stream:      []byte{0x9f, 0x81, 0xff},
+			err:         errCBORBreak,
+		},
+		{
+			description: "invalid key",
+			stream:      []byte{0xa1, 0xf5, 0x01},
+			err:         errBinaryMapKey,
+		},
+		{
+			description: "reserved additional information",
+			stream:      []byte{0x1c},
+			err:         errBinaryInvalid,
+		},
+		{
+			description: "epoch above int64",
+			stream:      []byte{0xc1, 0x1b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+			err:         errBinaryInvalid,
+		},
+		{
+			description: "infinite epoch",
+			stream:      []byte{0xc1, 0xfb, 0x7f, 0xf0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			err:         errBinaryInvalid,
+		},
+		{
+			description: "nested tags",
+			stream:      append(bytes.Repeat([]byte{0xc6}, maxBinaryDepth+1), 0x00),
+			err:         errBinaryDepth,
+		},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			_, err := InferCBOR(bytes.NewReader(tc.stream), WithoutHints())
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,613 @@
+// Package avro exports inferred JTD schemas as Avro schemas.
+package avro
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	jtd "github.com/jsontypedef/json-typedef-go"
+
+	jtdinfer "github.com/bombsimon/jtd-infer-go"
+)
+
+// ErrUnknownRef is returned when a schema references a definition that doesn't
+// exist.
+var ErrUnknownRef = errors.New("unknown ref")
+
+// ErrRecursiveRef is returned when a definition references itself without a
+// record in between since Avro can only reference named types.
+var ErrRecursiveRef = errors.New("recursive ref")
+
+// Options holds the options for the exported schema.
+type Options struct {
+	// Namespace is the namespace of the named types at the root, i.e. the root
+	// record or enum or the records in a root union. All nested named types
+	// inherit the namespace.
+	Namespace string
+	// Naming returns the name for a named type (record or enum) given the path
+	// from the root name to the type. Defaults to `DefaultNaming`. The name is
+	// sanitized and made unique after being returned.
+	Naming func(path []string) string
+}
+
+// Result is the exported Avro schema together with warnings for parts of the
+// schema that couldn't be represented exactly.
+type Result struct {
+	// Schema is the Avro schema which is either a string, a slice for unions or
+	// a struct for complex types.
+	Schema   any
+	Warnings []Warning
+}
+
+// JSON returns the Avro schema as indented JSON.
+func (r *Result) JSON() ([]byte, error) {
+	b, err := json.MarshalIndent(r.Schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("avro: %w", err)
+	}
+
+	return b, nil
+}
+
+// Warning describes a part of the schema that couldn't be represented exactly.
+type Warning struct {
+	// Path is the path to the value in the JTD schema, e.g. `/address/city`.
+	Path    string
+	Message string
+}
+
+// String implements the `fmt.Stringer` interface.
+func (w Warning) String() string {
+	return w.Path + ": " + w.Message
+}
+
+// Record represents an Avro record.
+type Record struct {
+	Type      string  `json:"type"`
+	Name      string  `json:"name"`
+	Namespace string  `json:"namespace,omitempty"`
+	Fields    []Field `json:"fields"`
+}
+
+// Field represents a field in an Avro record.
+type Field struct {
+	Name    string          `json:"name"`
+	Type    any             `json:"type"`
+	Default json.RawMessage `json:"default,omitempty"`
+}
+
+// Enum represents an Avro enum.
+type Enum struct {
+	Type      string   `json:"type"`
+	Name      string   `json:"name"`
+	Namespace string   `json:"namespace,omitempty"`
+	Symbols   []string `json:"symbols"`
+}
+
+// Array represents an Avro array.
+type Array struct {
+	Type  string `json:"type"`
+	Items any    `json:"items"`
+}
+
+// Map represents an Avro map.
+type Map struct {
+	Type   string `json:"type"`
+	Values any    `json:"values"`
+}
+
+// Logical represents a primitive type annotated with a logical type.
+type Logical struct {
+	Type        string `json:"type"`
+	LogicalType string `json:"logicalType"`
+}
+
+// DefaultNaming joins all parts of the path converted to PascalCase.
+func DefaultNaming(path []string) string {
+	var sb strings.Builder
+	for _, part := range path {
+		sb.WriteString(pascalCase(part))
+	}
+
+	return sb.String()
+}
+
+// Generate will export the schema as an Avro schema with the root record named
+// after the passed name. Optional and nullable properties are represented as a
+// union with null with null as default and discriminators are represented as a
+// union of records where each record holds the tag as a field with the mapping
+// key as default.
+func Generate(name string, schema jtdinfer.Schema, opts Options) (*Result, error) {
+	if opts.Naming == nil {
+		opts.Naming = DefaultNaming
+	}
+
+	e := &exporter{
+		opts:        opts,
+		definitions: schema.Definitions,
+		defined:     map[string]any{},
+		converting:  map[string]struct{}{},
+		names:       map[string]struct{}{},
+	}
+
+	avroSchema, err := e.convert([]string{name}, "", schema)
+	if err != nil {
+		return nil, err
+	}
+
+	setNamespace(avroSchema, opts.Namespace)
+
+	return &Result{
+		Schema:   avroSchema,
+		Warnings: e.warnings,
+	}, nil
+}
+
+type exporter struct {
+	opts        Options
+	definitions map[string]jtdinfer.Schema
+	defined     map[string]any
+	converting  map[string]struct{}
+	names       map[string]struct{}
+	warnings    []Warning
+}
+
+func (e *exporter) warn(path, format string, args ...any) {
+	if path == "" {
+		path = "/"
+	}
+
+	e.warnings = append(e.warnings, Warning{
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// name returns a unique name for a named type at the passed path.
+func (e *exporter) name(path []string) string {
+	name := sanitize(e.opts.Naming(path))
+	if name == "" {
+		name = "Type"
+	}
+
+	candidate := name
+	for n := 2; ; n++ {
+		if _, ok := e.names[candidate]; !ok {
+			break
+		}
+
+		candidate = name + strconv.Itoa(n)
+	}
+
+	e.names[candidate] = struct{}{}
+
+	return candidate
+}
+
+// convert converts a schema where the name path is used to name named types
+// and the pointer is the path used in warnings.
+func (e *exporter) convert(path []string, pointer string, schema jtdinfer.Schema) (any, error) {
+	avroSchema, err := e.convertNonNullable(path, pointer, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.isNullable(schema) {
+		return nullable(avroSchema), nil
+	}
+
+	return avroSchema, nil
+}
+
+// isNullable returns true if the schema or the definition it references is
+// nullable.
+func (e *exporter) isNullable(schema jtdinfer.Schema) bool {
+	seen := map[string]struct{}{}
+
+	for !schema.Nullable && schema.Ref != nil {
+		if _, ok := seen[*schema.Ref]; ok {
+			return false
+		}
+
+		seen[*schema.Ref] = struct{}{}
+		schema = e.definitions[*schema.Ref]
+	}
+
+	return schema.Nullable
+}
+
+func (e *exporter) convertNonNullable(path []string, pointer string, schema jtdinfer.Schema) (any, error) {
+	switch {
+	case schema.Ref != nil:
+		return e.convertRef(pointer, *schema.Ref)
+	case schema.Type != "":
+		return primitive(schema), nil
+	case schema.Enum != nil:
+		return e.convertEnum(e.name(path), pointer, schema.Enum), nil
+	case schema.Elements != nil:
+		items, err := e.convert(childPath(path, "Element"), pointer+"/-", *schema.Elements)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Array{Type: "array", Items: items}, nil
+	case schema.Values != nil:
+		values, err := e.convert(childPath(path, "Value"), pointer+"/-", *schema.Values)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Map{Type: "map", Values: values}, nil
+	case schema.Properties != nil || schema.OptionalProperties != nil:
+		return e.convertRecord(e.name(path), path, pointer, schema, "", "")
+	case schema.Discriminator != "":
+		return e.convertDiscriminator(e.mappingNames(path, schema), path, pointer, schema)
+	}
+
+	e.warn(pointer, "any value can't be represented, using string")
+
+	return "string", nil
+}
+
+// convertRef returns the definition the first time it's referenced and the
+// names of the named types in the definition after that. Names of records are
+// reserved before converting them so a record can reference itself.
+func (e *exporter) convertRef(pointer, ref string) (any, error) {
+	if reference, ok := e.defined[ref]; ok {
+		return reference, nil
+	}
+
+	definition, ok := e.definitions[ref]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownRef, ref)
+	}
+
+	if _, ok := e.converting[ref]; ok {
+		return nil, fmt.Errorf("%w: %s", ErrRecursiveRef, ref)
+	}
+
+	e.converting[ref] = struct{}{}
+	defer delete(e.converting, ref)
+
+	path := []string{ref}
+
+	switch {
+	case definition.Properties != nil || definition.OptionalProperties != nil:
+		name := e.name(path)
+		e.defined[ref] = name
+
+		return e.convertRecord(name, path, pointer, definition, "", "")
+	case definition.Discriminator != "":
+		names := e.mappingNames(path, definition)
+
+		union := make([]any, len(names))
+		for i, name := range names {
+			union[i] = name
+		}
+
+		e.defined[ref] = union
+
+		return e.convertDiscriminator(names, path, pointer, definition)
+	}
+
+	avroSchema, err := e.convertNonNullable(path, pointer, definition)
+	if err != nil {
+		return nil, err
+	}
+
+	e.defined[ref] = reference(avroSchema)
+
+	return avroSchema, nil
+}
+
+func (e *exporter) convertEnum(name, pointer string, values []string) *Enum {
+	symbols := make([]string, 0, len(values))
+	seen := map[string]string{}
+
+	for _, v := range sortedStrings(values) {
+		symbol := sanitize(v)
+
+		if symbol == "" {
+			e.warn(pointer, "enum value %q can't be represented as a symbol", v)
+			continue
+		}
+
+		if other, ok := seen[symbol]; ok {
+			e.warn(pointer, "enum value %q has the same symbol %q as %q", v, symbol, other)
+			continue
+		}
+
+		seen[symbol] = v
+		symbols = append(symbols, symbol)
+	}
+
+	return &Enum{
+		Type:    "enum",
+		Name:    name,
+		Symbols: symbols,
+	}
+}
+
+// convertRecord converts an object to a record with the passed name. If a tag
+// is passed it will be added as the first field with the tag value as default.
+func (e *exporter) convertRecord(
+	name string,
+	path []string,
+	pointer string,
+	schema jtdinfer.Schema,
+	tag, tagValue string,
+) (*Record, error) {
+	record := &Record{
+		Type:   "record",
+		Name:   name,
+		Fields: []Field{},
+	}
+
+	seen := map[string]string{}
+
+	addField := func(key string, field Field) {
+		if field.Name == "" {
+			e.warn(pointer+"/"+key, "property %q can't be represented as a field name", key)
+			return
+		}
+
+		if other, ok := seen[field.Name]; ok {
+			e.warn(pointer+"/"+key, "property %q has the same field name %q as %q", key, field.Name, other)
+			return
+		}
+
+		if field.Name != key {
+			e.warn(pointer+"/"+key, "property %q is renamed to the field name %q", key, field.Name)
+		}
+
+		seen[field.Name] = key
+		record.Fields = append(record.Fields, field)
+	}
+
+	if tag != "" {
+		tagDefault, _ := json.Marshal(tagValue)
+		addField(tag, Field{Name: sanitize(tag), Type: "string", Default: tagDefault})
+	}
+
+	keys := make([]string, 0, len(schema.Properties)+len(schema.OptionalProperties))
+	keys = append(keys, sortedKeys(schema.Properties)...)
+	keys = append(keys, sortedKeys(schema.OptionalProperties)...)
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		property, required := schema.Properties[k]
+		if !required {
+			property = schema.OptionalProperties[k]
+		}
+
+		fieldType, err := e.convert(childPath(path, k), pointer+"/"+k, property)
+		if err != nil {
+			return nil, err
+		}
+
+		field := Field{Name: sanitize(k), Type: fieldType}
+
+		if !required {
+			field.Type = nullable(fieldType)
+		}
+
+		if !required || e.isNullable(property) {
+			field.Default = json.RawMessage("null")
+		}
+
+		addField(k, field)
+	}
+
+	return record, nil
+}
+
+// mappingNames returns a unique name for the record of each mapping sorted by
+// the mapping key.
+func (e *exporter) mappingNames(path []string, schema jtdinfer.Schema) []string {
+	names := make([]string, 0, len(schema.Mapping))
+	for _, k := range sortedKeys(schema.Mapping) {
+		names = append(names, e.name(childPath(path, k)))
+	}
+
+	return names
+}
+
+// convertDiscriminator converts a discriminator to a union of records named by
+// the names from `mappingNames`.
+func (e *exporter) convertDiscriminator(
+	names []string,
+	path []string,
+	pointer string,
+	schema jtdinfer.Schema,
+) (any, error) {
+	union := make([]any, 0, len(schema.Mapping))
+
+	for i, k := range sortedKeys(schema.Mapping) {
+		record, err := e.convertRecord(
+			names[i],
+			childPath(path, k),
+			pointer,
+			schema.Mapping[k],
+			schema.Discriminator,
+			k,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		union = append(union, record)
+	}
+
+	return union, nil
+}
+
+// nullable returns a union with null as the first type. Unions are flattened
+// since Avro doesn't allow unions directly in unions.
+func nullable(avroSchema any) any {
+	union, ok := avroSchema.([]any)
+	if !ok {
+		return []any{"null", avroSchema}
+	}
+
+	if len(union) > 0 && union[0] == "null" {
+		return union
+	}
+
+	return append([]any{"null"}, union...)
+}
+
+// reference returns the schema with named types replaced by their names so it
+// can be used again without defining the named types twice.
+func reference(avroSchema any) any {
+	switch v := avroSchema.(type) {
+	case *Record:
+		return v.Name
+	case *Enum:
+		return v.Name
+	case *Array:
+		return &Array{Type: v.Type, Items: reference(v.Items)}
+	case *Map:
+		return &Map{Type: v.Type, Values: reference(v.Values)}
+	case []any:
+		union := make([]any, len(v))
+		for i := range v {
+			union[i] = reference(v[i])
+		}
+
+		return union
+	}
+
+	return avroSchema
+}
+
+// setNamespace sets the namespace for the named types at the root. Named types
+// in records aren't changed since they inherit the namespace of the record.
+func setNamespace(avroSchema any, namespace string) {
+	switch v := avroSchema.(type) {
+	case *Record:
+		v.Namespace = namespace
+	case *Enum:
+		v.Namespace = namespace
+	case *Array:
+		setNamespace(v.Items, namespace)
+	case *Map:
+		setNamespace(v.Values, namespace)
+	case []any:
+		for _, t := range v {
+			setNamespace(t, namespace)
+		}
+	}
+}
+
+// primitive returns the Avro type for the JTD type. Strings holding binary
+// values are represented as bytes.
+func primitive(schema jtdinfer.Schema) any {
+	switch schema.Type {
+	case jtd.TypeBoolean:
+		return "boolean"
+	case jtd.TypeString:
+		if schema.Metadata["format"] == jtdinfer.MetadataFormatBytes {
+			return "bytes"
+		}
+
+		return "string"
+	case jtd.TypeTimestamp:
+		return &Logical{Type: "long", LogicalType: "timestamp-millis"}
+	case jtd.TypeInt8, jtd.TypeUint8, jtd.TypeInt16, jtd.TypeUint16, jtd.TypeInt32:
+		return "int"
+	case jtd.TypeUint32:
+		return "long"
+	case jtd.TypeFloat32:
+		return "float"
+	case jtd.TypeFloat64:
+		return "double"
+	}
+
+	return "string"
+}
+
+// sanitize converts a value to a valid Avro name matching
+// `[A-Za-z_][A-Za-z0-9_]*` by replacing invalid characters with an underscore
+// and prefixing names starting with a digit with an underscore. An empty string
+// is returned if the value doesn't contain any valid characters.
+func sanitize(value string) string {
+	var sb strings.Builder
+
+	hasValid := false
+
+	for _, r := range value {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_':
+			hasValid = true
+		case r >= '0' && r <= '9':
+			hasValid = true
+
+			if sb.Len() == 0 {
+				sb.WriteRune('_')
+			}
+		default:
+			r = '_'
+		}
+
+		sb.WriteRune(r)
+	}
+
+	if !hasValid {
+		return ""
+	}
+
+	return sb.String()
+}
+
+func pascalCase(value string) string {
+	var sb strings.Builder
+
+	upper := true
+
+	for _, r := range value {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
+
+// childPath returns a copy of the path with the part appended.
+func childPath(path []string, part string) []string {
+	child := make([]string, len(path), len(path)+1)
+	copy(child, path)
+
+	return append(child, part)
+}
+
+func sortedKeys(m map[string]jtdinfer.Schema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func sortedStrings(values []string) []string {
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+
+	return sorted
+}
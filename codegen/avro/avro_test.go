@@ -0,0 +1,357 @@
+package avro
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jtdinfer "github.com/bombsimon/jtd-infer-go"
+)
+
+func TestGenerate(t *testing.T) {
+	rows := []string{
+		`{
+			"id": 1,
+			"created_at": "2023-01-01T00:00:00Z",
+			"name": null,
+			"status": "in-progress",
+			"scores": {"x": 1.5},
+			"tags": ["a"],
+			"event": {"type": "click", "x": 300}
+		}`,
+		`{
+			"id": 4000000000,
+			"created_at": "2023-01-01T00:00:00Z",
+			"name": "Joe",
+			"status": "done",
+			"scores": {},
+			"tags": [],
+			"event": {"type": "key", "key": "a"},
+			"extra": true
+		}`,
+	}
+
+	hints := jtdinfer.Hints{
+		Enums:         jtdinfer.NewHintSet().Add([]string{"status"}),
+		Values:        jtdinfer.NewHintSet().Add([]string{"scores"}),
+		Discriminator: jtdinfer.NewHintSet().Add([]string{"event", "type"}),
+	}
+
+	schema := jtdinfer.InferStrings(rows, hints).IntoSchema()
+
+	result, err := Generate("user", schema, Options{Namespace: "com.example"})
+	require.NoError(t, err)
+	assert.Empty(t, result.Warnings)
+
+	got, err := result.JSON()
+	require.NoError(t, err)
+
+	expected := `{
+  "type": "record",
+  "name": "User",
+  "namespace": "com.example",
+  "fields": [
+    {
+      "name": "created_at",
+      "type": {
+        "type": "long",
+        "logicalType": "timestamp-millis"
+      }
+    },
+    {
+      "name": "event",
+      "type": [
+        {
+          "type": "record",
+          "name": "UserEventClick",
+          "fields": [
+            {
+              "name": "type",
+              "type": "string",
+              "default": "click"
+            },
+            {
+              "name": "x",
+              "type": "int"
+            }
+          ]
+        },
+        {
+          "type": "record",
+          "name": "UserEventKey",
+          "fields": [
+            {
+              "name": "type",
+              "type": "string",
+              "default": "key"
+            },
+            {
+              "name": "key",
+              "type": "string"
+            }
+          ]
+        }
+      ]
+    },
+    {
+      "name": "extra",
+      "type": [
+        "null",
+        "boolean"
+      ],
+      "default": null
+    },
+    {
+      "name": "id",
+      "type": "long"
+    },
+    {
+      "name": "name",
+      "type": [
+        "null",
+        "string"
+      ],
+      "default": null
+    },
+    {
+      "name": "scores",
+      "type": {
+        "type": "map",
+        "values": "double"
+      }
+    },
+    {
+      "name": "status",
+      "type": {
+        "type": "enum",
+        "name": "UserStatus",
+        "symbols": [
+          "done",
+          "in_progress"
+        ]
+      }
+    },
+    {
+      "name": "tags",
+      "type": {
+        "type": "array",
+        "items": "string"
+      }
+    }
+  ]
+}`
+
+	assert.Equal(t, expected, string(got))
+}
+
+func TestGenerateWarnings(t *testing.T) {
+	schema := jtdinfer.Schema{
+		Properties: map[string]jtdinfer.Schema{
+			"status": {Enum: []string{"a-b", "a_b", "???", "1st"}},
+			"any":    {},
+			"a-b":    {Type: "string"},
+			"a_b":    {Type: "string"},
+		},
+	}
+
+	result, err := Generate("Row", schema, Options{})
+	require.NoError(t, err)
+
+	warnings := make([]string, 0, len(result.Warnings))
+	for _, w := range result.Warnings {
+		warnings = append(warnings, w.String())
+	}
+
+	assert.Equal(t, []string{
+		`/a-b: property "a-b" is renamed to the field name "a_b"`,
+		`/a_b: property "a_b" has the same field name "a_b" as "a-b"`,
+		`/any: any value can't be represented, using string`,
+		`/status: enum value "???" can't be represented as a symbol`,
+		`/status: enum value "a_b" has the same symbol "a_b" as "a-b"`,
+	}, warnings)
+
+	record, ok := result.Schema.(*Record)
+	require.True(t, ok)
+
+	for _, field := range record.Fields {
+		if field.Name == "status" {
+			enum, ok := field.Type.(*Enum)
+			require.True(t, ok)
+			assert.Equal(t, []string{"_1st", "a_b"}, enum.Symbols)
+		}
+	}
+}
+
+func TestGenerateRefsAndNaming(t *testing.T) {
+	ref := "address"
+	schema := jtdinfer.Schema{
+		Definitions: map[string]jtdinfer.Schema{
+			"address": {
+				Properties: map[string]jtdinfer.Schema{
+					"city": {Type: "string"},
+				},
+			},
+		},
+		Properties: map[string]jtdinfer.Schema{
+			"home": {Ref: &ref, Nullable: true},
+			"work": {Ref: &ref},
+		},
+	}
+
+	naming := func(path []string) string {
+		return strings.ToUpper(strings.Join(path, "_"))
+	}
+
+	result, err := Generate("person", schema, Options{Naming: naming})
+	require.NoError(t, err)
+
+	record, ok := result.Schema.(*Record)
+	require.True(t, ok)
+	assert.Equal(t, "PERSON", record.Name)
+
+	home, ok := record.Fields[0].Type.([]any)
+	require.True(t, ok)
+	assert.Equal(t, "null", home[0])
+
+	address, ok := home[1].(*Record)
+	require.True(t, ok)
+	assert.Equal(t, "ADDRESS", address.Name)
+
+	// The second reference should only use the name.
+	assert.Equal(t, "ADDRESS", record.Fields[1].Type)
+
+	unknown := "missing"
+	_, err = Generate("person", jtdinfer.Schema{Ref: &unknown}, Options{})
+	require.ErrorIs(t, err, ErrUnknownRef)
+}
+
+func TestGenerateDefinitions(t *testing.T) {
+	addr, node, event, id, alias := "addr", "node", "event", "id", "alias"
+	schema := jtdinfer.Schema{
+		Definitions: map[string]jtdinfer.Schema{
+			"addr": {
+				Nullable: true,
+				Properties: map[string]jtdinfer.Schema{
+					"city": {Type: "string"},
+				},
+			},
+			"node": {
+				Properties: map[string]jtdinfer.Schema{
+					"children": {Elements: &jtdinfer.Schema{Ref: &node}},
+					"next":     {Ref: &node, Nullable: true},
+				},
+			},
+			"event": {
+				Discriminator: "type",
+				Mapping: map[string]jtdinfer.Schema{
+					"a": {Properties: map[string]jtdinfer.Schema{"x": {Type: "string"}}},
+					"b": {Properties: map[string]jtdinfer.Schema{"y": {Type: "string"}}},
+				},
+			},
+			"id": {Type: "timestamp"},
+		},
+		Properties: map[string]jtdinfer.Schema{
+			"a_home":  {Ref: &addr},
+			"b_work":  {Ref: &addr},
+			"c_tree":  {Ref: &node},
+			"d_first": {Ref: &event},
+			"e_last":  {Ref: &event},
+			"f_id":    {Ref: &id},
+			"g_id":    {Ref: &id},
+		},
+	}
+
+	result, err := Generate("person", schema, Options{})
+	require.NoError(t, err)
+
+	record, ok := result.Schema.(*Record)
+	require.True(t, ok)
+	require.Len(t, record.Fields, 7)
+
+	for _, field := range record.Fields[:2] {
+		union, ok := field.Type.([]any)
+		require.True(t, ok, field.Name)
+		assert.Equal(t, "null", union[0], field.Name)
+		assert.Equal(t, "null", string(field.Default), field.Name)
+	}
+
+	home, ok := record.Fields[0].Type.([]any)
+	require.True(t, ok)
+	assert.Equal(t, "Addr", home[1].(*Record).Name)
+	assert.Equal(t, []any{"null", "Addr"}, record.Fields[1].Type)
+
+	tree, ok := record.Fields[2].Type.(*Record)
+	require.True(t, ok)
+	assert.Equal(t, []Field{
+		{Name: "children", Type: &Array{Type: "array", Items: "Node"}},
+		{Name: "next", Type: []any{"null", "Node"}, Default: []byte("null")},
+	}, tree.Fields)
+
+	first, ok := record.Fields[3].Type.([]any)
+	require.True(t, ok)
+	require.Len(t, first, 2)
+	assert.Equal(t, "EventA", first[0].(*Record).Name)
+	assert.Equal(t, "EventB", first[1].(*Record).Name)
+	assert.Equal(t, []any{"EventA", "EventB"}, record.Fields[4].Type)
+
+	assert.Equal(t, record.Fields[5].Type, record.Fields[6].Type)
+
+	_, err = Generate("person", jtdinfer.Schema{
+		Definitions: map[string]jtdinfer.Schema{
+			"alias": {Elements: &jtdinfer.Schema{Ref: &alias}},
+		},
+		Ref: &alias,
+	}, Options{})
+	require.ErrorIs(t, err, ErrRecursiveRef)
+}
+
+func TestGenerateRootNamespace(t *testing.T) {
+	opts := Options{Namespace: "com.example"}
+
+	result, err := Generate("status", jtdinfer.Schema{Enum: []string{"a", "b"}}, opts)
+	require.NoError(t, err)
+
+	enum, ok := result.Schema.(*Enum)
+	require.True(t, ok)
+	assert.Equal(t, "com.example", enum.Namespace)
+
+	event := jtdinfer.Schema{
+		Discriminator: "type",
+		Nullable:      true,
+		Mapping: map[string]jtdinfer.Schema{
+			"a": {Properties: map[string]jtdinfer.Schema{"x": {Type: "string"}}},
+			"b": {Properties: map[string]jtdinfer.Schema{"y": {Type: "string"}}},
+		},
+	}
+
+	result, err = Generate("event", event, opts)
+	require.NoError(t, err)
+
+	union, ok := result.Schema.([]any)
+	require.True(t, ok)
+	require.Len(t, union, 3)
+	assert.Equal(t, "null", union[0])
+
+	for _, v := range union[1:] {
+		record, ok := v.(*Record)
+		require.True(t, ok)
+		assert.Equal(t, "com.example", record.Namespace)
+	}
+}
+
+func TestGenerateBytes(t *testing.T) {
+	schema := jtdinfer.Schema{
+		Properties: map[string]jtdinfer.Schema{
+			"data": {Type: "string", Metadata: map[string]any{"format": jtdinfer.MetadataFormatBytes}},
+		},
+	}
+
+	result, err := Generate("blob", schema, Options{})
+	require.NoError(t, err)
+
+	record, ok := result.Schema.(*Record)
+	require.True(t, ok)
+	assert.Equal(t, []Field{{Name: "data", Type: "bytes"}}, record.Fields)
+}
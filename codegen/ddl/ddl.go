@@ -0,0 +1,570 @@
+// Package ddl generates SQL table definitions from inferred JTD schemas.
+package ddl
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	jtd "github.com/jsontypedef/json-typedef-go"
+
+	jtdinfer "github.com/bombsimon/jtd-infer-go"
+)
+
+// Names of the columns added to link child tables to their parent.
+const (
+	idColumn       = "_id"
+	parentIDColumn = "_parent_id"
+	indexColumn    = "_index"
+	keyColumn      = "_key"
+	valueColumn    = "value"
+)
+
+var (
+	// ErrNotObject is returned when the root schema isn't an object.
+	ErrNotObject = errors.New("root schema must be an object")
+
+	// ErrInvalidStrategy is returned when a strategy can't be used for the
+	// schema at the path.
+	ErrInvalidStrategy = errors.New("invalid strategy")
+
+	// ErrColumnConflict is returned when two properties are flattened to the
+	// same column. The same property in different discriminator mappings can
+	// share a column if the type is the same.
+	ErrColumnConflict = errors.New("column conflict")
+
+	// ErrUnknownRef is returned when a schema references a definition that
+	// doesn't exist.
+	ErrUnknownRef = errors.New("unknown ref")
+)
+
+// Dialect is the SQL dialect to generate.
+type Dialect uint8
+
+// Available dialects.
+const (
+	DialectPostgres Dialect = iota
+	DialectSQLite
+)
+
+// Strategy decides how nested objects, elements and values are stored.
+type Strategy uint8
+
+// Available strategies.
+const (
+	// StrategyDefault flattens objects and discriminators and stores elements
+	// and values as JSON.
+	StrategyDefault Strategy = iota
+	// StrategyFlatten stores each property of an object or discriminator as a
+	// column named after the path joined with the separator.
+	StrategyFlatten
+	// StrategyJSON stores the value as JSON in a `jsonb` column for PostgreSQL
+	// or a `text` column for SQLite.
+	StrategyJSON
+	// StrategyChildTable stores elements or values in a child table with a
+	// foreign key to the parent table.
+	StrategyChildTable
+)
+
+// EnumStyle decides how enums are represented.
+type EnumStyle uint8
+
+// Available enum styles.
+const (
+	// EnumCheck stores enums as text with a CHECK constraint.
+	EnumCheck EnumStyle = iota
+	// EnumNative creates a native enum type. Only supported by PostgreSQL,
+	// SQLite will use a CHECK constraint.
+	EnumNative
+)
+
+// Options holds the options for the generated DDL.
+type Options struct {
+	Dialect Dialect
+	// Separator is used to join the path for flattened columns and child
+	// tables, defaults to "_".
+	Separator string
+	Enums     EnumStyle
+	// Paths sets the strategy for the property at the path. The path is the
+	// property names from the root separated by a slash, e.g. `/address` or
+	// `/orders/items` where orders is stored in a child table.
+	Paths map[string]Strategy
+}
+
+// Generate will generate `CREATE TABLE` statements for the schema with the root
+// table named after the passed table. Required properties that aren't nullable
+// are marked as `NOT NULL`. Child tables gets a `_parent_id` column referencing
+// the `_id` column of the parent table and an `_index` column for elements or
+// a `_key` column for values. The two columns are the primary key of the child
+// table unless it has child tables itself, then `_id` is the primary key and
+// the two columns are unique.
+func Generate(table string, schema jtdinfer.Schema, opts Options) (string, error) {
+	if schema.Ref != nil {
+		definition, ok := schema.Definitions[*schema.Ref]
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrUnknownRef, *schema.Ref)
+		}
+
+		definition.Definitions = schema.Definitions
+		schema = definition
+	}
+
+	if !isObject(schema) && schema.Discriminator == "" {
+		return "", ErrNotObject
+	}
+
+	if opts.Separator == "" {
+		opts.Separator = "_"
+	}
+
+	g := &generator{
+		opts:        opts,
+		definitions: schema.Definitions,
+	}
+
+	root := g.newTable(table)
+	if err := g.flatten(root, nil, "", schema, true); err != nil {
+		return "", err
+	}
+
+	statements := g.renderTypes()
+	for _, t := range g.tables {
+		statements = append(statements, g.render(t))
+	}
+
+	return strings.Join(statements, "\n"), nil
+}
+
+type table struct {
+	name        string
+	parent      *table
+	linkColumn  string
+	columns     []column
+	hasChildren bool
+}
+
+type column struct {
+	name string
+	// path is the path to the property the column is for, used to only merge
+	// columns for the same property in different discriminator mappings.
+	path    string
+	typ     string
+	notNull bool
+	// allowed is the allowed values for enums and discriminator tags which are
+	// stored in a native enum type if native is set or else checked with a
+	// CHECK constraint.
+	allowed []string
+	native  bool
+}
+
+type generator struct {
+	opts        Options
+	definitions map[string]jtdinfer.Schema
+	tables      []*table
+}
+
+func (g *generator) newTable(name string) *table {
+	t := &table{name: name}
+	g.tables = append(g.tables, t)
+
+	return t
+}
+
+// flatten adds a column for each property in an object or discriminator.
+func (g *generator) flatten(t *table, prefix []string, path string, schema jtdinfer.Schema, required bool) error {
+	if schema.Discriminator != "" {
+		return g.discriminator(t, prefix, path, schema, required)
+	}
+
+	keys := make([]string, 0, len(schema.Properties)+len(schema.OptionalProperties))
+	keys = append(keys, sortedKeys(schema.Properties)...)
+	keys = append(keys, sortedKeys(schema.OptionalProperties)...)
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		property, isRequired := schema.Properties[k]
+		if !isRequired {
+			property = schema.OptionalProperties[k]
+		}
+
+		columnPrefix := append(prefix[:len(prefix):len(prefix)], k)
+		if err := g.value(t, columnPrefix, path+"/"+k, property, required && isRequired); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// discriminator adds a column for the tag and flattens all mappings. All
+// mapping properties are nullable since they're only set for some mappings.
+func (g *generator) discriminator(t *table, prefix []string, path string, schema jtdinfer.Schema, required bool) error {
+	tagValues := sortedKeys(schema.Mapping)
+
+	if err := g.addColumn(t, column{
+		name:    g.columnName(append(prefix[:len(prefix):len(prefix)], schema.Discriminator)),
+		path:    path + "/" + schema.Discriminator,
+		typ:     g.textType(),
+		notNull: required && !schema.Nullable,
+	}, tagValues); err != nil {
+		return err
+	}
+
+	for _, k := range tagValues {
+		if err := g.flatten(t, prefix, path, schema.Mapping[k], false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// value adds the column or columns for a value using the strategy configured
+// for the path.
+func (g *generator) value(t *table, prefix []string, path string, schema jtdinfer.Schema, required bool) error {
+	return g.valueWithStrategy(t, prefix, path, schema, required, g.opts.Paths[path])
+}
+
+func (g *generator) valueWithStrategy(
+	t *table,
+	prefix []string,
+	path string,
+	schema jtdinfer.Schema,
+	required bool,
+	strategy Strategy,
+) error {
+	if schema.Ref != nil {
+		definition, ok := g.definitions[*schema.Ref]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownRef, *schema.Ref)
+		}
+
+		definition.Nullable = definition.Nullable || schema.Nullable
+		schema = definition
+	}
+
+	required = required && !schema.Nullable
+
+	switch {
+	case isObject(schema) || schema.Discriminator != "":
+		switch strategy {
+		case StrategyDefault, StrategyFlatten:
+			return g.flatten(t, prefix, path, schema, required)
+		case StrategyJSON:
+			return g.addColumn(t, column{name: g.columnName(prefix), path: path, typ: g.jsonType(), notNull: required}, nil)
+		case StrategyChildTable:
+		}
+
+		return fmt.Errorf("%w: child table for object at %s", ErrInvalidStrategy, path)
+	case schema.Elements != nil || schema.Values != nil:
+		switch strategy {
+		case StrategyDefault, StrategyJSON:
+			return g.addColumn(t, column{name: g.columnName(prefix), path: path, typ: g.jsonType(), notNull: required}, nil)
+		case StrategyChildTable:
+			return g.childTable(t, prefix, path, schema)
+		case StrategyFlatten:
+		}
+
+		return fmt.Errorf("%w: flatten for elements or values at %s", ErrInvalidStrategy, path)
+	}
+
+	if strategy != StrategyDefault && strategy != StrategyJSON {
+		return fmt.Errorf("%w: only JSON can be used for primitive at %s", ErrInvalidStrategy, path)
+	}
+
+	c := column{name: g.columnName(prefix), path: path, notNull: required}
+
+	if strategy == StrategyJSON {
+		c.typ = g.jsonType()
+		return g.addColumn(t, c, nil)
+	}
+
+	if schema.Enum != nil {
+		return g.enum(t, c, schema.Enum)
+	}
+
+	c.typ = g.primitive(schema.Type)
+
+	return g.addColumn(t, c, nil)
+}
+
+// childTable adds a table for elements or values linked to the parent table.
+func (g *generator) childTable(parent *table, prefix []string, path string, schema jtdinfer.Schema) error {
+	parent.hasChildren = true
+
+	child := g.newTable(parent.name + g.opts.Separator + g.columnName(prefix))
+	child.parent = parent
+
+	inner := schema.Elements
+	child.linkColumn = indexColumn
+
+	if inner == nil {
+		inner = schema.Values
+		child.linkColumn = keyColumn
+	}
+
+	if isObject(*inner) || inner.Discriminator != "" {
+		return g.flatten(child, nil, path, *inner, !inner.Nullable)
+	}
+
+	// The strategy for the path is already used for the child table so the
+	// element or value itself uses the default strategy.
+	return g.valueWithStrategy(child, []string{valueColumn}, path, *inner, true, StrategyDefault)
+}
+
+// enum adds a column for an enum. For native enums the column gets a type
+// named after the table and column which is created with the allowed values of
+// the column when rendering.
+func (g *generator) enum(t *table, c column, values []string) error {
+	if g.opts.Enums != EnumNative || g.opts.Dialect != DialectPostgres {
+		c.typ = g.textType()
+		return g.addColumn(t, c, values)
+	}
+
+	c.typ = quoteIdentifier(t.name + g.opts.Separator + c.name)
+	c.native = true
+
+	return g.addColumn(t, c, values)
+}
+
+// addColumn adds a column to the table. If allowed values are passed only
+// those values are allowed in the column. Adding the same column twice is
+// allowed if it's for the same property with the same type which happens when
+// flattening discriminators, the allowed values are then merged.
+func (g *generator) addColumn(t *table, c column, allowed []string) error {
+	c.allowed = sortedStrings(allowed)
+
+	for i, existing := range t.columns {
+		if existing.name != c.name {
+			continue
+		}
+
+		if existing.path != c.path || existing.typ != c.typ {
+			return fmt.Errorf("%w: %s in %s", ErrColumnConflict, c.name, t.name)
+		}
+
+		t.columns[i].notNull = existing.notNull && c.notNull
+		t.columns[i].allowed = mergeAllowed(existing.allowed, c.allowed)
+
+		return nil
+	}
+
+	t.columns = append(t.columns, c)
+
+	return nil
+}
+
+// renderTypes returns a `CREATE TYPE` statement for each native enum column.
+func (g *generator) renderTypes() []string {
+	statements := []string{}
+
+	for _, t := range g.tables {
+		for _, c := range t.columns {
+			if !c.native {
+				continue
+			}
+
+			statements = append(statements, fmt.Sprintf(
+				"CREATE TYPE %s AS ENUM (%s);\n",
+				c.typ,
+				strings.Join(quoteLiterals(c.allowed), ", "),
+			))
+		}
+	}
+
+	return statements
+}
+
+func (g *generator) render(t *table) string {
+	lines := []string{}
+
+	if t.hasChildren {
+		idType := "bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY"
+		if g.opts.Dialect == DialectSQLite {
+			idType = "integer PRIMARY KEY"
+		}
+
+		lines = append(lines, fmt.Sprintf("%s %s", quoteIdentifier(idColumn), idType))
+	}
+
+	if t.parent != nil {
+		idType, linkType := "bigint", "integer"
+		if t.linkColumn == keyColumn {
+			linkType = g.textType()
+		}
+
+		if g.opts.Dialect == DialectSQLite {
+			idType = "integer"
+		}
+
+		lines = append(
+			lines,
+			fmt.Sprintf(
+				"%s %s NOT NULL REFERENCES %s (%s)",
+				quoteIdentifier(parentIDColumn),
+				idType,
+				quoteIdentifier(t.parent.name),
+				quoteIdentifier(idColumn),
+			),
+			fmt.Sprintf("%s %s NOT NULL", quoteIdentifier(t.linkColumn), linkType),
+		)
+	}
+
+	for _, c := range t.columns {
+		line := quoteIdentifier(c.name) + " " + c.typ
+		if c.notNull {
+			line += " NOT NULL"
+		}
+
+		if len(c.allowed) > 0 && !c.native {
+			line += fmt.Sprintf(
+				" CHECK (%s IN (%s))",
+				quoteIdentifier(c.name),
+				strings.Join(quoteLiterals(c.allowed), ", "),
+			)
+		}
+
+		lines = append(lines, line)
+	}
+
+	// A table with children already has `_id` as primary key so the link to
+	// the parent is only unique.
+	if t.parent != nil {
+		constraint := "PRIMARY KEY"
+		if t.hasChildren {
+			constraint = "UNIQUE"
+		}
+
+		lines = append(lines, fmt.Sprintf(
+			"%s (%s, %s)",
+			constraint,
+			quoteIdentifier(parentIDColumn),
+			quoteIdentifier(t.linkColumn),
+		))
+	}
+
+	return fmt.Sprintf(
+		"CREATE TABLE %s (\n  %s\n);\n",
+		quoteIdentifier(t.name),
+		strings.Join(lines, ",\n  "),
+	)
+}
+
+func (g *generator) columnName(prefix []string) string {
+	return strings.Join(prefix, g.opts.Separator)
+}
+
+func (g *generator) primitive(t jtd.Type) string {
+	if g.opts.Dialect == DialectSQLite {
+		switch t {
+		case jtd.TypeBoolean,
+			jtd.TypeInt8, jtd.TypeUint8,
+			jtd.TypeInt16, jtd.TypeUint16,
+			jtd.TypeInt32, jtd.TypeUint32:
+			return "integer"
+		case jtd.TypeFloat32, jtd.TypeFloat64:
+			return "real"
+		case jtd.TypeString, jtd.TypeTimestamp:
+			return "text"
+		}
+
+		return g.jsonType()
+	}
+
+	switch t {
+	case jtd.TypeBoolean:
+		return "boolean"
+	case jtd.TypeInt8, jtd.TypeUint8, jtd.TypeInt16:
+		return "smallint"
+	case jtd.TypeUint16, jtd.TypeInt32:
+		return "integer"
+	case jtd.TypeUint32:
+		return "bigint"
+	case jtd.TypeFloat32:
+		return "real"
+	case jtd.TypeFloat64:
+		return "double precision"
+	case jtd.TypeTimestamp:
+		return "timestamptz"
+	case jtd.TypeString:
+		return "text"
+	}
+
+	return g.jsonType()
+}
+
+func (g *generator) jsonType() string {
+	if g.opts.Dialect == DialectSQLite {
+		return "text"
+	}
+
+	return "jsonb"
+}
+
+func (g *generator) textType() string {
+	return "text"
+}
+
+func isObject(schema jtdinfer.Schema) bool {
+	return schema.Properties != nil || schema.OptionalProperties != nil
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+func quoteLiterals(values []string) []string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = quoteLiteral(v)
+	}
+
+	return quoted
+}
+
+// mergeAllowed returns the sorted union of the allowed values. If any of the
+// columns allows any value the merged column does too.
+func mergeAllowed(a, b []string) []string {
+	if len(a) == 0 || len(b) == 0 {
+		return nil
+	}
+
+	seen := map[string]struct{}{}
+	merged := []string{}
+
+	for _, v := range append(append([]string{}, a...), b...) {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+
+		seen[v] = struct{}{}
+		merged = append(merged, v)
+	}
+
+	sort.Strings(merged)
+
+	return merged
+}
+
+func sortedKeys(m map[string]jtdinfer.Schema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func sortedStrings(values []string) []string {
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+
+	return sorted
+}
@@ -0,0 +1,266 @@
+package ddl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jtdinfer "github.com/bombsimon/jtd-infer-go"
+)
+
+var rows = []string{
+	`{
+		"id": 1,
+		"created_at": "2023-01-01T00:00:00Z",
+		"name": null,
+		"status": "done",
+		"address": {"city": "Stockholm", "zip": "12345"},
+		"tags": ["a"],
+		"scores": {"x": 1.5},
+		"event": {"type": "click", "x": 300}
+	}`,
+	`{
+		"id": 4000000000,
+		"created_at": "2023-01-01T00:00:00Z",
+		"name": "Joe",
+		"status": "it's new",
+		"address": {"city": "Oslo"},
+		"tags": [],
+		"scores": {},
+		"event": {"type": "key", "key": "a"}
+	}`,
+}
+
+var hints = jtdinfer.Hints{
+	Enums:         jtdinfer.NewHintSet().Add([]string{"status"}),
+	Values:        jtdinfer.NewHintSet().Add([]string{"scores"}),
+	Discriminator: jtdinfer.NewHintSet().Add([]string{"event", "type"}),
+}
+
+func TestGenerate(t *testing.T) {
+	schema := jtdinfer.InferStrings(rows, hints).IntoSchema()
+
+	got, err := Generate("users", schema, Options{})
+	require.NoError(t, err)
+
+	expected := `CREATE TABLE "users" (
+  "address_city" text NOT NULL,
+  "address_zip" text,
+  "created_at" timestamptz NOT NULL,
+  "event_type" text NOT NULL CHECK ("event_type" IN ('click', 'key')),
+  "event_x" integer,
+  "event_key" text,
+  "id" bigint NOT NULL,
+  "name" text,
+  "scores" jsonb NOT NULL,
+  "status" text NOT NULL CHECK ("status" IN ('done', 'it''s new')),
+  "tags" jsonb NOT NULL
+);
+`
+
+	assert.Equal(t, expected, got)
+}
+
+func TestGenerateChildTables(t *testing.T) {
+	schema := jtdinfer.InferStrings(rows, hints).IntoSchema()
+
+	for _, tc := range []struct {
+		dialect  Dialect
+		expected string
+	}{
+		{
+			dialect: DialectPostgres,
+			expected: `CREATE TYPE "users__status" AS ENUM ('done', 'it''s new');
+
+CREATE TABLE "users" (
+  "_id" bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+  "address" jsonb NOT NULL,
+  "created_at" timestamptz NOT NULL,
+  "event__type" text NOT NULL CHECK ("event__type" IN ('click', 'key')),
+  "event__x" integer,
+  "event__key" text,
+  "id" bigint NOT NULL,
+  "name" text,
+  "status" "users__status" NOT NULL
+);
+
+CREATE TABLE "users__scores" (
+  "_parent_id" bigint NOT NULL REFERENCES "users" ("_id"),
+  "_key" text NOT NULL,
+  "value" double precision NOT NULL,
+  PRIMARY KEY ("_parent_id", "_key")
+);
+
+CREATE TABLE "users__tags" (
+  "_parent_id" bigint NOT NULL REFERENCES "users" ("_id"),
+  "_index" integer NOT NULL,
+  "value" text NOT NULL,
+  PRIMARY KEY ("_parent_id", "_index")
+);
+`,
+		},
+		{
+			dialect: DialectSQLite,
+			expected: `CREATE TABLE "users" (
+  "_id" integer PRIMARY KEY,
+  "address" text NOT NULL,
+  "created_at" text NOT NULL,
+  "event__type" text NOT NULL CHECK ("event__type" IN ('click', 'key')),
+  "event__x" integer,
+  "event__key" text,
+  "id" integer NOT NULL,
+  "name" text,
+  "status" text NOT NULL CHECK ("status" IN ('done', 'it''s new'))
+);
+
+CREATE TABLE "users__scores" (
+  "_parent_id" integer NOT NULL REFERENCES "users" ("_id"),
+  "_key" text NOT NULL,
+  "value" real NOT NULL,
+  PRIMARY KEY ("_parent_id", "_key")
+);
+
+CREATE TABLE "users__tags" (
+  "_parent_id" integer NOT NULL REFERENCES "users" ("_id"),
+  "_index" integer NOT NULL,
+  "value" text NOT NULL,
+  PRIMARY KEY ("_parent_id", "_index")
+);
+`,
+		},
+	} {
+		t.Run(fmt.Sprintf("dialect %d", tc.dialect), func(t *testing.T) {
+			got, err := Generate("users", schema, Options{
+				Dialect:   tc.dialect,
+				Separator: "__",
+				Enums:     EnumNative,
+				Paths: map[string]Strategy{
+					"/address": StrategyJSON,
+					"/tags":    StrategyChildTable,
+					"/scores":  StrategyChildTable,
+				},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	schema := jtdinfer.InferStrings(rows, hints).IntoSchema()
+
+	_, err := Generate("users", schema, Options{
+		Paths: map[string]Strategy{"/address": StrategyChildTable},
+	})
+	require.ErrorIs(t, err, ErrInvalidStrategy)
+
+	_, err = Generate("users", schema, Options{
+		Paths: map[string]Strategy{"/tags": StrategyFlatten},
+	})
+	require.ErrorIs(t, err, ErrInvalidStrategy)
+
+	_, err = Generate("users", jtdinfer.InferStrings([]string{`[1]`}, hints).IntoSchema(), Options{})
+	require.ErrorIs(t, err, ErrNotObject)
+
+	for _, row := range []string{`{"a_b": 1, "a": {"b": "x"}}`, `{"a_b": 1, "a": {"b": 2}}`} {
+		conflict := jtdinfer.InferStrings([]string{row}, jtdinfer.WithoutHints()).IntoSchema()
+		_, err = Generate("t", conflict, Options{})
+		require.ErrorIs(t, err, ErrColumnConflict, row)
+	}
+}
+
+func TestGenerateNestedChildTables(t *testing.T) {
+	schema := jtdinfer.InferStrings([]string{
+		`{"orders": [{"id": 1, "items": [{"sku": "a"}]}]}`,
+	}, jtdinfer.WithoutHints()).IntoSchema()
+
+	got, err := Generate("users", schema, Options{
+		Paths: map[string]Strategy{
+			"/orders":       StrategyChildTable,
+			"/orders/items": StrategyChildTable,
+		},
+	})
+	require.NoError(t, err)
+
+	expected := `CREATE TABLE "users" (
+  "_id" bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY
+);
+
+CREATE TABLE "users_orders" (
+  "_id" bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+  "_parent_id" bigint NOT NULL REFERENCES "users" ("_id"),
+  "_index" integer NOT NULL,
+  "id" smallint NOT NULL,
+  UNIQUE ("_parent_id", "_index")
+);
+
+CREATE TABLE "users_orders_items" (
+  "_parent_id" bigint NOT NULL REFERENCES "users_orders" ("_id"),
+  "_index" integer NOT NULL,
+  "sku" text NOT NULL,
+  PRIMARY KEY ("_parent_id", "_index")
+);
+`
+
+	assert.Equal(t, expected, got)
+}
+
+func TestGenerateDiscriminatorColumns(t *testing.T) {
+	schema := jtdinfer.InferStrings([]string{
+		`{"event": {"type": "a", "x": 1}}`,
+		`{"event": {"type": "b", "x": 2}}`,
+	}, jtdinfer.Hints{
+		Discriminator: jtdinfer.NewHintSet().Add([]string{"event", "type"}),
+	}).IntoSchema()
+
+	got, err := Generate("t", schema, Options{})
+	require.NoError(t, err)
+
+	expected := `CREATE TABLE "t" (
+  "event_type" text NOT NULL CHECK ("event_type" IN ('a', 'b')),
+  "event_x" smallint
+);
+`
+
+	assert.Equal(t, expected, got)
+}
+
+func TestGenerateDiscriminatorEnums(t *testing.T) {
+	schema := jtdinfer.Schema{
+		Properties: map[string]jtdinfer.Schema{
+			"event": {
+				Discriminator: "type",
+				Mapping: map[string]jtdinfer.Schema{
+					"a": {Properties: map[string]jtdinfer.Schema{"status": {Enum: []string{"done", "new"}}}},
+					"b": {Properties: map[string]jtdinfer.Schema{"status": {Enum: []string{"new", "open"}}}},
+				},
+			},
+		},
+	}
+
+	got, err := Generate("t", schema, Options{})
+	require.NoError(t, err)
+
+	expected := `CREATE TABLE "t" (
+  "event_type" text NOT NULL CHECK ("event_type" IN ('a', 'b')),
+  "event_status" text CHECK ("event_status" IN ('done', 'new', 'open'))
+);
+`
+
+	assert.Equal(t, expected, got)
+
+	got, err = Generate("t", schema, Options{Enums: EnumNative})
+	require.NoError(t, err)
+
+	expected = `CREATE TYPE "t_event_status" AS ENUM ('done', 'new', 'open');
+
+CREATE TABLE "t" (
+  "event_type" text NOT NULL CHECK ("event_type" IN ('a', 'b')),
+  "event_status" "t_event_status"
+);
+`
+
+	assert.Equal(t, expected, got)
+}
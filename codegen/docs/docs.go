@@ -0,0 +1,327 @@
+// Package docs renders inferred JTD schemas as human readable Markdown or HTML
+// references.
+package docs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	jtdinfer "github.com/bombsimon/jtd-infer-go"
+)
+
+// Metadata keys read from the schema when rendering examples and presence.
+const (
+	// ExamplesKey holds a list of example values for the field.
+	ExamplesKey = jtdinfer.MetadataExamples
+	// PresenceKey holds the fraction, between 0 and 1, of the objects where
+	// the field was present. It's set when inferring with `Presence` in the
+	// hints.
+	PresenceKey = jtdinfer.MetadataPresence
+)
+
+// ErrUnknownRef is returned when a schema references a definition that doesn't
+// exist.
+var ErrUnknownRef = errors.New("unknown ref")
+
+// section is one heading in the document, usually with a table of fields.
+type section struct {
+	Title       string
+	Anchor      string
+	Description []inline
+	Rows        []row
+	Variants    []variant
+}
+
+// variant links a discriminator tag value to the section for the mapping.
+type variant struct {
+	Value  string
+	Anchor string
+}
+
+type row struct {
+	Field    string
+	Type     inline
+	Required bool
+	Nullable bool
+	Enum     []string
+	Examples []string
+	Presence string
+}
+
+// inline is a piece of text where the code is optionally linked to an anchor,
+// e.g. "array of " followed by a link to the element section.
+type inline struct {
+	Prefix string
+	Code   string
+	Anchor string
+}
+
+type renderer struct {
+	definitions map[string]jtdinfer.Schema
+	sections    []*section
+	anchors     map[string]struct{}
+	refs        map[string]string
+}
+
+// build walks the schema and returns all sections in the order they should be
+// rendered, the root first followed by nested sections and definitions.
+func build(title string, schema jtdinfer.Schema) ([]*section, error) {
+	r := &renderer{
+		definitions: schema.Definitions,
+		anchors:     map[string]struct{}{},
+		refs:        map[string]string{},
+	}
+
+	definitionNames := make([]string, 0, len(schema.Definitions))
+	for k := range schema.Definitions {
+		definitionNames = append(definitionNames, k)
+	}
+
+	sort.Strings(definitionNames)
+
+	// Reserve the anchors for the root and all definitions first since they
+	// can be referenced from anywhere.
+	rootAnchor := r.reserve(title)
+	for _, k := range definitionNames {
+		r.refs[k] = r.reserve(k)
+	}
+
+	if err := r.section(title, rootAnchor, schema); err != nil {
+		return nil, err
+	}
+
+	for _, k := range definitionNames {
+		if err := r.section(k, r.refs[k], schema.Definitions[k]); err != nil {
+			return nil, err
+		}
+	}
+
+	return r.sections, nil
+}
+
+// section adds a section for the schema. Objects get a table with all fields,
+// discriminators get a list of variants and everything else a single line with
+// the type.
+func (r *renderer) section(title, anchor string, schema jtdinfer.Schema) error {
+	s := &section{Title: title, Anchor: anchor}
+	r.sections = append(r.sections, s)
+
+	switch {
+	case schema.Discriminator != "":
+		s.Description = []inline{{Prefix: "One of the variants below selected by ", Code: schema.Discriminator}}
+
+		for _, k := range sortedKeys(schema.Mapping) {
+			variantTitle := fmt.Sprintf("%s (%s = %s)", title, schema.Discriminator, k)
+			v := variant{Value: k, Anchor: r.reserve(variantTitle)}
+			s.Variants = append(s.Variants, v)
+
+			if err := r.section(variantTitle, v.Anchor, schema.Mapping[k]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case schema.Properties != nil || schema.OptionalProperties != nil:
+		keys := append(sortedKeys(schema.Properties), sortedKeys(schema.OptionalProperties)...)
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			property, required := schema.Properties[k]
+			if !required {
+				property = schema.OptionalProperties[k]
+			}
+
+			typ, err := r.typeOf(title+"."+k, property)
+			if err != nil {
+				return err
+			}
+
+			s.Rows = append(s.Rows, row{
+				Field:    k,
+				Type:     typ,
+				Required: required,
+				Nullable: property.Nullable,
+				Enum:     sortedStrings(property.Enum),
+				Examples: examples(property),
+				Presence: presence(property),
+			})
+		}
+
+		return nil
+	}
+
+	typ, err := r.typeOf(title, schema)
+	if err != nil {
+		return err
+	}
+
+	typ.Prefix = "Type: " + typ.Prefix
+	s.Description = []inline{typ}
+
+	return nil
+}
+
+// typeOf returns the description of the type and adds sections for nested
+// objects and discriminators.
+func (r *renderer) typeOf(path string, schema jtdinfer.Schema) (inline, error) {
+	switch {
+	case schema.Ref != nil:
+		anchor, ok := r.refs[*schema.Ref]
+		if !ok {
+			return inline{}, fmt.Errorf("%w: %s", ErrUnknownRef, *schema.Ref)
+		}
+
+		return inline{Code: *schema.Ref, Anchor: anchor}, nil
+	case schema.Properties != nil || schema.OptionalProperties != nil || schema.Discriminator != "":
+		anchor := r.reserve(path)
+		if err := r.section(path, anchor, schema); err != nil {
+			return inline{}, err
+		}
+
+		text := "object"
+		if schema.Discriminator != "" {
+			text = "discriminator"
+		}
+
+		return inline{Code: text, Anchor: anchor}, nil
+	case schema.Elements != nil:
+		inner, err := r.typeOf(path+"[]", *schema.Elements)
+		if err != nil {
+			return inline{}, err
+		}
+
+		inner.Prefix = "array of " + inner.Prefix
+
+		return inner, nil
+	case schema.Values != nil:
+		inner, err := r.typeOf(path+"{}", *schema.Values)
+		if err != nil {
+			return inline{}, err
+		}
+
+		inner.Prefix = "map of " + inner.Prefix
+
+		return inner, nil
+	case schema.Enum != nil:
+		return inline{Code: "enum"}, nil
+	case schema.Type != "":
+		return inline{Code: string(schema.Type)}, nil
+	}
+
+	return inline{Code: "any"}, nil
+}
+
+// reserve returns a unique anchor for the title.
+func (r *renderer) reserve(title string) string {
+	base := Anchor(title)
+	anchor := base
+
+	for i := 2; ; i++ {
+		if _, ok := r.anchors[anchor]; !ok {
+			break
+		}
+
+		anchor = base + "-" + strconv.Itoa(i)
+	}
+
+	r.anchors[anchor] = struct{}{}
+
+	return anchor
+}
+
+// Anchor converts a title to the anchor used for the section, lower cased with
+// everything but letters and digits replaced by a single dash.
+func Anchor(title string) string {
+	var sb strings.Builder
+
+	dash := false
+
+	for _, r := range strings.ToLower(title) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			dash = sb.Len() > 0
+			continue
+		}
+
+		if dash {
+			sb.WriteRune('-')
+			dash = false
+		}
+
+		sb.WriteRune(r)
+	}
+
+	if sb.Len() == 0 {
+		return "section"
+	}
+
+	return sb.String()
+}
+
+func examples(schema jtdinfer.Schema) []string {
+	values, ok := schema.Metadata[ExamplesKey].([]any)
+	if !ok {
+		return nil
+	}
+
+	result := make([]string, 0, len(values))
+
+	for _, v := range values {
+		b, err := json.Marshal(v)
+		if err != nil {
+			continue
+		}
+
+		result = append(result, string(b))
+	}
+
+	return result
+}
+
+func presence(schema jtdinfer.Schema) string {
+	var fraction float64
+
+	switch v := schema.Metadata[PresenceKey].(type) {
+	case float64:
+		fraction = v
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return ""
+		}
+
+		fraction = f
+	default:
+		return ""
+	}
+
+	// Round to one decimal, e.g. 75.4%.
+	return strconv.FormatFloat(math.Round(fraction*1000)/10, 'f', -1, 64) + "%"
+}
+
+func sortedKeys(m map[string]jtdinfer.Schema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func sortedStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+
+	return sorted
+}
@@ -0,0 +1,129 @@
+package docs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jtdinfer "github.com/bombsimon/jtd-infer-go"
+)
+
+func testSchema() jtdinfer.Schema {
+	rows := []string{
+		`{"id": 1, "status": "done", "address": {"city": "Oslo"}, "tags": ["a"], "event": {"type": "click", "x": 1}}`,
+		`{"id": 2, "status": "new", "address": null, "event": {"type": "key", "key": "a|b"}}`,
+	}
+
+	hints := jtdinfer.Hints{
+		Enums:         jtdinfer.NewHintSet().Add([]string{"status"}),
+		Discriminator: jtdinfer.NewHintSet().Add([]string{"event", "type"}),
+	}
+
+	schema := jtdinfer.InferStrings(rows, hints).IntoSchema()
+
+	id := schema.Properties["id"]
+	id.Metadata = map[string]any{ExamplesKey: []any{1, 2}}
+	schema.Properties["id"] = id
+
+	tags := schema.OptionalProperties["tags"]
+	tags.Metadata = map[string]any{PresenceKey: 0.5}
+	schema.OptionalProperties["tags"] = tags
+
+	return schema
+}
+
+func TestMarkdown(t *testing.T) {
+	got, err := Markdown("User", testSchema())
+	require.NoError(t, err)
+
+	expected := `<a id="user"></a>
+
+## User
+
+| Field | Type | Required | Nullable | Values | Examples | Presence |
+| --- | --- | --- | --- | --- | --- | --- |
+| ` + "`address`" + ` | [` + "`object`" + `](#user-address) | yes | yes |  |  |  |
+| ` + "`event`" + ` | [` + "`discriminator`" + `](#user-event) | yes | no |  |  |  |
+| ` + "`id`" + ` | ` + "`uint8`" + ` | yes | no |  | ` + "`1`, `2`" + ` |  |
+| ` + "`status`" + ` | ` + "`enum`" + ` | yes | no | ` + "`done`, `new`" + ` |  |  |
+| ` + "`tags`" + ` | array of ` + "`string`" + ` | no | no |  |  | 50% |
+
+<a id="user-address"></a>
+
+## User.address
+
+| Field | Type | Required | Nullable |
+| --- | --- | --- | --- |
+| ` + "`city`" + ` | ` + "`string`" + ` | yes | no |
+
+<a id="user-event"></a>
+
+## User.event
+
+One of the variants below selected by ` + "`type`" + `.
+
+- [` + "`click`" + `](#user-event-type-click)
+- [` + "`key`" + `](#user-event-type-key)
+
+<a id="user-event-type-click"></a>
+
+## User.event (type = click)
+
+| Field | Type | Required | Nullable |
+| --- | --- | --- | --- |
+| ` + "`x`" + ` | ` + "`uint8`" + ` | yes | no |
+
+<a id="user-event-type-key"></a>
+
+## User.event (type = key)
+
+| Field | Type | Required | Nullable |
+| --- | --- | --- | --- |
+| ` + "`key`" + ` | ` + "`string`" + ` | yes | no |
+`
+
+	assert.Equal(t, expected, got)
+}
+
+func TestMarkdownInferredPresence(t *testing.T) {
+	rows := []string{`{"id": 1, "name": "a"}`, `{"id": 2}`, `{"id": 3}`}
+	schema := jtdinfer.InferStrings(rows, jtdinfer.Hints{Presence: true}).IntoSchema()
+
+	got, err := Markdown("User", schema)
+	require.NoError(t, err)
+
+	expected := `<a id="user"></a>
+
+## User
+
+| Field | Type | Required | Nullable | Presence |
+| --- | --- | --- | --- | --- |
+| ` + "`id`" + ` | ` + "`uint8`" + ` | yes | no | 100% |
+| ` + "`name`" + ` | ` + "`string`" + ` | no | no | 33.3% |
+`
+
+	assert.Equal(t, expected, got)
+}
+
+func TestHTML(t *testing.T) {
+	got, err := HTML("User <v1>", testSchema())
+	require.NoError(t, err)
+
+	assert.Contains(t, got, "<title>User &lt;v1&gt;</title>")
+	assert.Contains(t, got, `<h2 id="user-v1-address">User &lt;v1&gt;.address</h2>`)
+	assert.Contains(t, got, `<td><a href="#user-v1-address"><code>object</code></a></td>`)
+	assert.Contains(t, got, `<td>array of <code>string</code></td>`)
+	assert.Contains(t, got, `<li><a href="#user-v1-event-type-click"><code>click</code></a></li>`)
+	assert.Contains(t, got, `<td><code>done</code>, <code>new</code></td>`)
+	assert.Contains(t, got, `<td>50%</td>`)
+}
+
+func TestUnknownRef(t *testing.T) {
+	ref := "missing"
+
+	_, err := Markdown("User", jtdinfer.Schema{
+		Properties: map[string]jtdinfer.Schema{"a": {Ref: &ref}},
+	})
+	require.ErrorIs(t, err, ErrUnknownRef)
+}
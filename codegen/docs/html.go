@@ -0,0 +1,100 @@
+package docs
+
+import (
+	"html/template"
+	"strings"
+
+	jtdinfer "github.com/bombsimon/jtd-infer-go"
+)
+
+var htmlTemplate = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+</style>
+</head>
+<body>
+{{- range .Sections }}
+<h2 id="{{ .Anchor }}">{{ .Title }}</h2>
+{{- if .Description }}
+<p>{{ range .Description }}{{ template "inline" . }}{{ end }}.</p>
+{{- end }}
+{{- if .Variants }}
+<ul>
+{{- range .Variants }}
+<li><a href="#{{ .Anchor }}"><code>{{ .Value }}</code></a></li>
+{{- end }}
+</ul>
+{{- end }}
+{{- if .Rows }}
+{{- $cols := .Columns }}
+<table>
+<tr><th>Field</th><th>Type</th><th>Required</th><th>Nullable</th>
+{{- if $cols.Enum }}<th>Values</th>{{ end }}
+{{- if $cols.Examples }}<th>Examples</th>{{ end }}
+{{- if $cols.Presence }}<th>Presence</th>{{ end }}</tr>
+{{- range .Rows }}
+<tr><td><code>{{ .Field }}</code></td><td>{{ template "inline" .Type }}</td>
+{{- if .Required }}<td>yes</td>{{ else }}<td>no</td>{{ end }}
+{{- if .Nullable }}<td>yes</td>{{ else }}<td>no</td>{{ end }}
+{{- if $cols.Enum }}<td>{{ template "codes" .Enum }}</td>{{ end }}
+{{- if $cols.Examples }}<td>{{ template "codes" .Examples }}</td>{{ end }}
+{{- if $cols.Presence }}<td>{{ .Presence }}</td>{{ end }}</tr>
+{{- end }}
+</table>
+{{- end }}
+{{- end }}
+</body>
+</html>
+{{ define "inline" }}{{ .Prefix }}
+{{- if .Anchor }}<a href="#{{ .Anchor }}"><code>{{ .Code }}</code></a>
+{{- else }}<code>{{ .Code }}</code>
+{{- end }}{{ end }}
+{{- define "codes" }}{{ range $i, $v := . }}{{ if $i }}, {{ end }}<code>{{ $v }}</code>{{ end }}{{ end }}`))
+
+// htmlSection adds the optional columns to the section for the template.
+type htmlSection struct {
+	*section
+	Columns htmlColumns
+}
+
+type htmlColumns struct {
+	Enum, Examples, Presence bool
+}
+
+// HTML renders the schema as a standalone HTML page with the same sections as
+// `Markdown`.
+func HTML(title string, schema jtdinfer.Schema) (string, error) {
+	sections, err := build(title, schema)
+	if err != nil {
+		return "", err
+	}
+
+	data := struct {
+		Title    string
+		Sections []htmlSection
+	}{
+		Title:    title,
+		Sections: make([]htmlSection, len(sections)),
+	}
+
+	for i, s := range sections {
+		cols := columnsFor(s.Rows)
+		data.Sections[i] = htmlSection{
+			section: s,
+			Columns: htmlColumns{Enum: cols.enum, Examples: cols.examples, Presence: cols.presence},
+		}
+	}
+
+	var sb strings.Builder
+	if err := htmlTemplate.Execute(&sb, data); err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,172 @@
+package docs
+
+import (
+	"strings"
+
+	jtdinfer "github.com/bombsimon/jtd-infer-go"
+)
+
+// Markdown renders the schema as a Markdown reference with one section per
+// object, discriminator and definition. Each section is preceded by an HTML
+// anchor so nested objects, variants and definitions can be linked to.
+func Markdown(title string, schema jtdinfer.Schema) (string, error) {
+	sections, err := build(title, schema)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+
+	for i, s := range sections {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+
+		sb.WriteString(`<a id="` + s.Anchor + `"></a>` + "\n\n")
+		sb.WriteString("## " + escapeMarkdown(s.Title) + "\n")
+
+		if len(s.Description) > 0 {
+			sb.WriteString("\n")
+
+			for _, d := range s.Description {
+				sb.WriteString(markdownInline(d))
+			}
+
+			sb.WriteString(".\n")
+		}
+
+		if len(s.Variants) > 0 {
+			sb.WriteString("\n")
+
+			for _, v := range s.Variants {
+				sb.WriteString("- " + markdownInline(inline{Code: v.Value, Anchor: v.Anchor}) + "\n")
+			}
+		}
+
+		if len(s.Rows) > 0 {
+			sb.WriteString("\n")
+			markdownTable(&sb, s.Rows)
+		}
+	}
+
+	return sb.String(), nil
+}
+
+func markdownTable(sb *strings.Builder, rows []row) {
+	cols := columnsFor(rows)
+
+	header := []string{"Field", "Type", "Required", "Nullable"}
+	if cols.enum {
+		header = append(header, "Values")
+	}
+
+	if cols.examples {
+		header = append(header, "Examples")
+	}
+
+	if cols.presence {
+		header = append(header, "Presence")
+	}
+
+	sb.WriteString("| " + strings.Join(header, " | ") + " |\n")
+	sb.WriteString("|" + strings.Repeat(" --- |", len(header)) + "\n")
+
+	for _, r := range rows {
+		cells := []string{
+			code(r.Field),
+			markdownInline(r.Type),
+			yesNo(r.Required),
+			yesNo(r.Nullable),
+		}
+
+		if cols.enum {
+			cells = append(cells, codeList(r.Enum))
+		}
+
+		if cols.examples {
+			cells = append(cells, codeList(r.Examples))
+		}
+
+		if cols.presence {
+			cells = append(cells, r.Presence)
+		}
+
+		sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+}
+
+func markdownInline(i inline) string {
+	if i.Anchor == "" {
+		return escapeMarkdown(i.Prefix) + code(i.Code)
+	}
+
+	return escapeMarkdown(i.Prefix) + "[" + code(i.Code) + "](#" + i.Anchor + ")"
+}
+
+func codeList(values []string) string {
+	codes := make([]string, len(values))
+	for i, v := range values {
+		codes[i] = code(v)
+	}
+
+	return strings.Join(codes, ", ")
+}
+
+// code wraps the value in backticks, using more backticks than the value
+// contains. Pipes are escaped since they end the table cell even in code.
+func code(value string) string {
+	fence := "`"
+	for strings.Contains(value, fence) {
+		fence += "`"
+	}
+
+	value = strings.ReplaceAll(value, "|", `\|`)
+	value = strings.ReplaceAll(value, "\n", " ")
+
+	if strings.HasPrefix(value, "`") || strings.HasSuffix(value, "`") {
+		value = " " + value + " "
+	}
+
+	return fence + value + fence
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	"*", `\*`,
+	"_", `\_`,
+	"[", `\[`,
+	"]", `\]`,
+	"<", `\<`,
+	"|", `\|`,
+	"\n", " ",
+)
+
+func escapeMarkdown(value string) string {
+	return markdownEscaper.Replace(value)
+}
+
+func yesNo(v bool) string {
+	if v {
+		return "yes"
+	}
+
+	return "no"
+}
+
+// columns holds which optional columns a table needs.
+type columns struct {
+	enum, examples, presence bool
+}
+
+func columnsFor(rows []row) columns {
+	var c columns
+
+	for _, r := range rows {
+		c.enum = c.enum || len(r.Enum) > 0
+		c.examples = c.examples || len(r.Examples) > 0
+		c.presence = c.presence || r.Presence != ""
+	}
+
+	return c
+}
@@ -0,0 +1,483 @@
+// Package openapi exports inferred JTD schemas as OpenAPI 3.1 components.
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	jtd "github.com/jsontypedef/json-typedef-go"
+	"gopkg.in/yaml.v3"
+
+	jtdinfer "github.com/bombsimon/jtd-infer-go"
+)
+
+// Version is the OpenAPI version of the exported documents.
+const Version = "3.1.0"
+
+// refPrefix is the prefix for references to component schemas.
+const refPrefix = "#/components/schemas/"
+
+var (
+	// ErrNameCollision is returned when two schemas or definitions would get
+	// the same component name.
+	ErrNameCollision = errors.New("component name collision")
+
+	// ErrUnknownRef is returned when a schema references a definition that
+	// doesn't exist.
+	ErrUnknownRef = errors.New("unknown ref")
+)
+
+// Options holds the information about the exported document.
+type Options struct {
+	// Title is the title of the API, defaults to "Inferred schemas".
+	Title string
+	// Version is the version of the API, defaults to "1.0.0".
+	Version string
+}
+
+// Document represents an OpenAPI document with only components.
+type Document struct {
+	OpenAPI    string     `json:"openapi"    yaml:"openapi"`
+	Info       Info       `json:"info"       yaml:"info"`
+	Components Components `json:"components" yaml:"components"`
+}
+
+// Info represents the info object of an OpenAPI document.
+type Info struct {
+	Title   string `json:"title"   yaml:"title"`
+	Version string `json:"version" yaml:"version"`
+}
+
+// Components represents the components of an OpenAPI document.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas" yaml:"schemas"`
+}
+
+// Schema represents an OpenAPI schema object. `Type` is either a string or a
+// list of strings for nullable types and `AdditionalProperties` is either a
+// boolean or a `*Schema`.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"                 yaml:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty"                 yaml:"type,omitempty"`
+	Format               string             `json:"format,omitempty"               yaml:"format,omitempty"`
+	ContentEncoding      string             `json:"contentEncoding,omitempty"      yaml:"contentEncoding,omitempty"`
+	Description          string             `json:"description,omitempty"          yaml:"description,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"                 yaml:"enum,omitempty"`
+	Minimum              *int64             `json:"minimum,omitempty"              yaml:"minimum,omitempty"`
+	Maximum              *int64             `json:"maximum,omitempty"              yaml:"maximum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"                yaml:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"           yaml:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"             yaml:"required,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"                yaml:"oneOf,omitempty"`
+	Discriminator        *Discriminator     `json:"discriminator,omitempty"        yaml:"discriminator,omitempty"`
+}
+
+// Discriminator represents an OpenAPI discriminator object.
+type Discriminator struct {
+	PropertyName string            `json:"propertyName"      yaml:"propertyName"`
+	Mapping      map[string]string `json:"mapping,omitempty" yaml:"mapping,omitempty"`
+}
+
+// Generate will export the named schemas as component schemas. Definitions in
+// the schemas are exported as components named after the definition and each
+// discriminator mapping is exported as a component named after the schema or
+// property holding the discriminator and the mapping key. Definitions with the
+// same name in several schemas are exported once if they are identical.
+func Generate(schemas map[string]jtdinfer.Schema, opts Options) (*Document, error) {
+	e := &exporter{
+		components: map[string]*Schema{},
+		reserved:   map[string]struct{}{},
+	}
+
+	names := sortedKeys(schemas)
+
+	// All root schemas and definitions are reserved first since they must get
+	// the exact name.
+	definitions := map[string]jtdinfer.Schema{}
+
+	for _, name := range names {
+		if err := e.reserveExact(name); err != nil {
+			return nil, err
+		}
+
+		for k, v := range schemas[name].Definitions {
+			if existing, ok := definitions[k]; ok && reflect.DeepEqual(existing, v) {
+				continue
+			}
+
+			if err := e.reserveExact(k); err != nil {
+				return nil, err
+			}
+
+			definitions[k] = v
+		}
+	}
+
+	e.definitions = definitions
+
+	for _, k := range sortedKeys(definitions) {
+		schema, err := e.convert(ComponentName(k), definitions[k])
+		if err != nil {
+			return nil, err
+		}
+
+		e.components[ComponentName(k)] = schema
+	}
+
+	for _, name := range names {
+		schema, err := e.convert(ComponentName(name), schemas[name])
+		if err != nil {
+			return nil, err
+		}
+
+		e.components[ComponentName(name)] = schema
+	}
+
+	if opts.Title == "" {
+		opts.Title = "Inferred schemas"
+	}
+
+	if opts.Version == "" {
+		opts.Version = "1.0.0"
+	}
+
+	return &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:   opts.Title,
+			Version: opts.Version,
+		},
+		Components: Components{
+			Schemas: e.components,
+		},
+	}, nil
+}
+
+// JSON returns the document as indented JSON.
+func (d *Document) JSON() ([]byte, error) {
+	b, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+
+	return b, nil
+}
+
+// YAML returns the document as YAML indented with two spaces.
+func (d *Document) YAML() ([]byte, error) {
+	var buf bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(d); err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ComponentName converts a name to a valid component name by replacing all
+// characters not matching `^[a-zA-Z0-9._-]+$` with an underscore.
+func ComponentName(name string) string {
+	if name == "" {
+		return "_"
+	}
+
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') ||
+			r == '.' || r == '_' || r == '-' {
+			return r
+		}
+
+		return '_'
+	}, name)
+}
+
+type exporter struct {
+	definitions map[string]jtdinfer.Schema
+	components  map[string]*Schema
+	reserved    map[string]struct{}
+}
+
+func (e *exporter) reserveExact(name string) error {
+	componentName := ComponentName(name)
+	if _, ok := e.reserved[componentName]; ok {
+		return fmt.Errorf("%w: %s", ErrNameCollision, componentName)
+	}
+
+	e.reserved[componentName] = struct{}{}
+
+	return nil
+}
+
+// reserve will reserve a unique component name based on the passed name.
+func (e *exporter) reserve(name string) string {
+	name = ComponentName(name)
+
+	candidate := name
+	for n := 2; ; n++ {
+		if _, ok := e.reserved[candidate]; !ok {
+			break
+		}
+
+		candidate = name + strconv.Itoa(n)
+	}
+
+	e.reserved[candidate] = struct{}{}
+
+	return candidate
+}
+
+// convert will convert a JTD schema to an OpenAPI schema. The name is used as
+// the base name for components created for discriminator mappings.
+func (e *exporter) convert(name string, schema jtdinfer.Schema) (*Schema, error) {
+	result, err := e.convertNonNullable(name, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	if description, ok := schema.Metadata["description"].(string); ok {
+		result.Description = description
+	}
+
+	if !schema.Nullable {
+		return result, nil
+	}
+
+	if t, ok := result.Type.(string); ok {
+		result.Type = []string{t, "null"}
+		if result.Enum != nil {
+			result.Enum = append(result.Enum, nil)
+		}
+
+		return result, nil
+	}
+
+	if result.Ref != "" || result.OneOf != nil {
+		return &Schema{OneOf: []*Schema{result, {Type: "null"}}}, nil
+	}
+
+	return result, nil
+}
+
+func (e *exporter) convertNonNullable(name string, schema jtdinfer.Schema) (*Schema, error) {
+	switch {
+	case schema.Ref != nil:
+		if _, ok := e.definitions[*schema.Ref]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownRef, *schema.Ref)
+		}
+
+		return &Schema{Ref: refPrefix + ComponentName(*schema.Ref)}, nil
+	case schema.Type != "":
+		return primitive(schema), nil
+	case schema.Enum != nil:
+		enum := make([]any, len(schema.Enum))
+		for i, v := range sortedStrings(schema.Enum) {
+			enum[i] = v
+		}
+
+		return &Schema{Type: "string", Enum: enum}, nil
+	case schema.Elements != nil:
+		items, err := e.convert(name+"Element", *schema.Elements)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Schema{Type: "array", Items: items}, nil
+	case schema.Values != nil:
+		values, err := e.convert(name+"Value", *schema.Values)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Schema{Type: "object", AdditionalProperties: values}, nil
+	case schema.Properties != nil || schema.OptionalProperties != nil:
+		return e.convertObject(name, schema, "", "")
+	case schema.Discriminator != "":
+		return e.convertDiscriminator(name, schema)
+	}
+
+	return &Schema{}, nil
+}
+
+// convertObject converts an object schema. If a tag is passed it will be added
+// as a required property with the tag value as the only allowed value.
+func (e *exporter) convertObject(name string, schema jtdinfer.Schema, tag, tagValue string) (*Schema, error) {
+	result := &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{},
+	}
+
+	if !schema.AdditionalProperties {
+		result.AdditionalProperties = false
+	}
+
+	if tag != "" {
+		result.Properties[tag] = &Schema{Type: "string", Enum: []any{tagValue}}
+		result.Required = append(result.Required, tag)
+	}
+
+	for _, k := range sortedKeys(schema.Properties) {
+		property, err := e.convert(name+propertySuffix(k), schema.Properties[k])
+		if err != nil {
+			return nil, err
+		}
+
+		result.Properties[k] = property
+		result.Required = append(result.Required, k)
+	}
+
+	for _, k := range sortedKeys(schema.OptionalProperties) {
+		property, err := e.convert(name+propertySuffix(k), schema.OptionalProperties[k])
+		if err != nil {
+			return nil, err
+		}
+
+		result.Properties[k] = property
+	}
+
+	sort.Strings(result.Required)
+
+	return result, nil
+}
+
+// convertDiscriminator converts a discriminator by adding a component for each
+// mapping and returning a schema that is one of the mappings.
+func (e *exporter) convertDiscriminator(name string, schema jtdinfer.Schema) (*Schema, error) {
+	result := &Schema{
+		OneOf: []*Schema{},
+		Discriminator: &Discriminator{
+			PropertyName: schema.Discriminator,
+			Mapping:      map[string]string{},
+		},
+	}
+
+	for _, k := range sortedKeys(schema.Mapping) {
+		componentName := e.reserve(name + propertySuffix(k))
+
+		variant, err := e.convertObject(componentName, schema.Mapping[k], schema.Discriminator, k)
+		if err != nil {
+			return nil, err
+		}
+
+		e.components[componentName] = variant
+		ref := refPrefix + componentName
+
+		result.OneOf = append(result.OneOf, &Schema{Ref: ref})
+		result.Discriminator.Mapping[k] = ref
+	}
+
+	return result, nil
+}
+
+func primitive(schema jtdinfer.Schema) *Schema {
+	switch schema.Type {
+	case jtd.TypeBoolean:
+		return &Schema{Type: "boolean"}
+	case jtd.TypeString:
+		result := &Schema{Type: "string"}
+
+		// Bytes are base64 encoded which is described by the content encoding
+		// since OpenAPI 3.1.
+		switch format, _ := schema.Metadata["format"].(string); format {
+		case jtdinfer.MetadataFormatBytes:
+			result.ContentEncoding = "base64"
+		default:
+			result.Format = format
+		}
+
+		return result
+	case jtd.TypeTimestamp:
+		return &Schema{Type: "string", Format: "date-time"}
+	case jtd.TypeFloat32:
+		return &Schema{Type: "number", Format: "float"}
+	case jtd.TypeFloat64:
+		return &Schema{Type: "number", Format: "double"}
+	case jtd.TypeInt8:
+		return integer(math.MinInt8, math.MaxInt8)
+	case jtd.TypeUint8:
+		return integer(0, math.MaxUint8)
+	case jtd.TypeInt16:
+		return integer(math.MinInt16, math.MaxInt16)
+	case jtd.TypeUint16:
+		return integer(0, math.MaxUint16)
+	case jtd.TypeInt32:
+		return integer(math.MinInt32, math.MaxInt32)
+	case jtd.TypeUint32:
+		return integer(0, math.MaxUint32)
+	}
+
+	return &Schema{}
+}
+
+// integer returns an integer schema with the range. The format is `int32`
+// unless the range doesn't fit in which case it's `int64`.
+func integer(minValue, maxValue int64) *Schema {
+	format := "int32"
+	if maxValue > math.MaxInt32 {
+		format = "int64"
+	}
+
+	return &Schema{
+		Type:    "integer",
+		Format:  format,
+		Minimum: &minValue,
+		Maximum: &maxValue,
+	}
+}
+
+// propertySuffix converts a property name to a suffix used in component
+// names, e.g. "user_id" becomes "UserId".
+func propertySuffix(name string) string {
+	var sb strings.Builder
+
+	upper := true
+
+	for _, r := range ComponentName(name) {
+		if r == '_' || r == '-' || r == '.' {
+			upper = true
+			continue
+		}
+
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
+
+func sortedKeys(m map[string]jtdinfer.Schema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func sortedStrings(values []string) []string {
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+
+	return sorted
+}
@@ -0,0 +1,220 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jtdinfer "github.com/bombsimon/jtd-infer-go"
+)
+
+func TestGenerate(t *testing.T) {
+	rows := []string{
+		`{
+			"id": 1,
+			"created_at": "2023-01-01T00:00:00Z",
+			"name": null,
+			"status": "active",
+			"scores": {"x": 1.5},
+			"event": {"type": "click", "x": 300},
+			"big": 9007199254740993
+		}`,
+		`{
+			"id": 2,
+			"created_at": "2023-01-01T00:00:00Z",
+			"name": "Joe",
+			"status": "inactive",
+			"scores": {},
+			"event": {"type": "key", "key": "a"},
+			"tags": ["a"]
+		}`,
+	}
+
+	hints := jtdinfer.Hints{
+		BigInt:        jtdinfer.BigIntAsString,
+		Enums:         jtdinfer.NewHintSet().Add([]string{"status"}),
+		Values:        jtdinfer.NewHintSet().Add([]string{"scores"}),
+		Discriminator: jtdinfer.NewHintSet().Add([]string{"event", "type"}),
+	}
+
+	schema := jtdinfer.InferStrings(rows, hints).IntoSchema()
+
+	document, err := Generate(map[string]jtdinfer.Schema{"User": schema}, Options{})
+	require.NoError(t, err)
+
+	got, err := document.YAML()
+	require.NoError(t, err)
+
+	expected := `openapi: 3.1.0
+info:
+  title: Inferred schemas
+  version: 1.0.0
+components:
+  schemas:
+    User:
+      type: object
+      properties:
+        big:
+          type: string
+          format: int64
+        created_at:
+          type: string
+          format: date-time
+        event:
+          oneOf:
+            - $ref: '#/components/schemas/UserEventClick'
+            - $ref: '#/components/schemas/UserEventKey'
+          discriminator:
+            propertyName: type
+            mapping:
+              click: '#/components/schemas/UserEventClick'
+              key: '#/components/schemas/UserEventKey'
+        id:
+          type: integer
+          format: int32
+          minimum: 0
+          maximum: 255
+        name:
+          type:
+            - string
+            - "null"
+        scores:
+          type: object
+          additionalProperties:
+            type: number
+            format: double
+        status:
+          type: string
+          enum:
+            - active
+            - inactive
+        tags:
+          type: array
+          items:
+            type: string
+      required:
+        - created_at
+        - event
+        - id
+        - name
+        - scores
+        - status
+      additionalProperties: false
+    UserEventClick:
+      type: object
+      properties:
+        type:
+          type: string
+          enum:
+            - click
+        x:
+          type: integer
+          format: int32
+          minimum: 0
+          maximum: 65535
+      required:
+        - type
+        - x
+      additionalProperties: false
+    UserEventKey:
+      type: object
+      properties:
+        key:
+          type: string
+        type:
+          type: string
+          enum:
+            - key
+      required:
+        - key
+        - type
+      additionalProperties: false
+`
+
+	assert.Equal(t, expected, string(got))
+
+	gotJSON, err := document.JSON()
+	require.NoError(t, err)
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(gotJSON, &decoded))
+	assert.Equal(t, "3.1.0", decoded["openapi"])
+}
+
+func TestGenerateDefinitionsAndNullable(t *testing.T) {
+	ref := "address"
+	schemas := map[string]jtdinfer.Schema{
+		"Person": {
+			Definitions: map[string]jtdinfer.Schema{
+				"address": {
+					Properties: map[string]jtdinfer.Schema{
+						"city": {Type: "string"},
+					},
+				},
+			},
+			Properties: map[string]jtdinfer.Schema{
+				"home":  {Ref: &ref, Nullable: true},
+				"work":  {Ref: &ref},
+				"level": {Enum: []string{"b", "a"}, Nullable: true},
+				"age":   {Type: "uint32"},
+			},
+		},
+	}
+
+	document, err := Generate(schemas, Options{Title: "People", Version: "2.0.0"})
+	require.NoError(t, err)
+
+	assert.Equal(t, Info{Title: "People", Version: "2.0.0"}, document.Info)
+
+	person := document.Components.Schemas["Person"]
+	assert.Equal(
+		t,
+		&Schema{OneOf: []*Schema{{Ref: "#/components/schemas/address"}, {Type: "null"}}},
+		person.Properties["home"],
+	)
+	assert.Equal(t, &Schema{Ref: "#/components/schemas/address"}, person.Properties["work"])
+	assert.Equal(
+		t,
+		&Schema{Type: []string{"string", "null"}, Enum: []any{"a", "b", nil}},
+		person.Properties["level"],
+	)
+	assert.Equal(t, "int64", person.Properties["age"].Format)
+	assert.Contains(t, document.Components.Schemas, "address")
+
+	_, err = Generate(map[string]jtdinfer.Schema{"address": {}, "Person": schemas["Person"]}, Options{})
+	require.ErrorIs(t, err, ErrNameCollision)
+
+	// Identical definitions in several schemas are exported once.
+	shared := map[string]jtdinfer.Schema{"Person": schemas["Person"], "Company": schemas["Person"]}
+	document, err = Generate(shared, Options{})
+	require.NoError(t, err)
+	assert.Len(t, document.Components.Schemas, 3)
+
+	other := jtdinfer.Schema{
+		Definitions: map[string]jtdinfer.Schema{"address": {Type: "string"}},
+	}
+	_, err = Generate(map[string]jtdinfer.Schema{"Person": schemas["Person"], "Company": other}, Options{})
+	require.ErrorIs(t, err, ErrNameCollision)
+
+	unknown := "missing"
+	_, err = Generate(map[string]jtdinfer.Schema{"Person": {Ref: &unknown}}, Options{})
+	require.ErrorIs(t, err, ErrUnknownRef)
+}
+
+func TestGenerateBytes(t *testing.T) {
+	schema := jtdinfer.Schema{
+		Properties: map[string]jtdinfer.Schema{
+			"data": {Type: "string", Metadata: map[string]any{"format": jtdinfer.MetadataFormatBytes}},
+			"id":   {Type: "string", Metadata: map[string]any{"format": "int64"}},
+		},
+	}
+
+	document, err := Generate(map[string]jtdinfer.Schema{"Blob": schema}, Options{})
+	require.NoError(t, err)
+
+	blob := document.Components.Schemas["Blob"]
+	assert.Equal(t, &Schema{Type: "string", ContentEncoding: "base64"}, blob.Properties["data"])
+	assert.Equal(t, &Schema{Type: "string", Format: "int64"}, blob.Properties["id"])
+}
@@ -0,0 +1,148 @@
+package protobuf
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Reserved field numbers that can't be used in messages.
+const (
+	firstReservedNumber = 19000
+	lastReservedNumber  = 19999
+)
+
+// Lock holds the field numbers for all messages and the value numbers for all
+// enums by their fully qualified name and the field or value name. Passing the
+// lock from a previous generation to `Generate` keeps the numbers stable and
+// reserves numbers for fields and values that no longer exist.
+type Lock struct {
+	Messages map[string]map[string]int `json:"messages"`
+	Enums    map[string]map[string]int `json:"enums"`
+}
+
+// NewLock returns a new empty `Lock`.
+func NewLock() *Lock {
+	return &Lock{
+		Messages: map[string]map[string]int{},
+		Enums:    map[string]map[string]int{},
+	}
+}
+
+// ReadLock reads a JSON encoded lock.
+func ReadLock(r io.Reader) (*Lock, error) {
+	lock := NewLock()
+	if err := json.NewDecoder(r).Decode(lock); err != nil {
+		return nil, fmt.Errorf("protobuf: invalid lock: %w", err)
+	}
+
+	if lock.Messages == nil {
+		lock.Messages = map[string]map[string]int{}
+	}
+
+	if lock.Enums == nil {
+		lock.Enums = map[string]map[string]int{}
+	}
+
+	return lock, nil
+}
+
+// Write writes the lock as indented JSON.
+func (l *Lock) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(l); err != nil {
+		return fmt.Errorf("protobuf: %w", err)
+	}
+
+	return nil
+}
+
+// clone returns a deep copy of the lock.
+func (l *Lock) clone() *Lock {
+	cloned := NewLock()
+
+	for k, v := range l.Messages {
+		cloned.Messages[k] = cloneNumbers(v)
+	}
+
+	for k, v := range l.Enums {
+		cloned.Enums[k] = cloneNumbers(v)
+	}
+
+	return cloned
+}
+
+// fieldNumber returns the locked number for the field or the next free number
+// if the field isn't locked.
+func (l *Lock) fieldNumber(message, field string) int {
+	return assignNumber(l.Messages, message, field)
+}
+
+// enumNumber returns the locked number for the enum value or the next free
+// number if the value isn't locked. Zero is always used by the unspecified
+// value.
+func (l *Lock) enumNumber(enum, value string) int {
+	return assignNumber(l.Enums, enum, value)
+}
+
+func assignNumber(locked map[string]map[string]int, scope, name string) int {
+	numbers, ok := locked[scope]
+	if !ok {
+		numbers = map[string]int{}
+		locked[scope] = numbers
+	}
+
+	if n, ok := numbers[name]; ok {
+		return n
+	}
+
+	next := 1
+	for _, n := range numbers {
+		if n >= next {
+			next = n + 1
+		}
+	}
+
+	if next >= firstReservedNumber && next <= lastReservedNumber {
+		next = lastReservedNumber + 1
+	}
+
+	numbers[name] = next
+
+	return next
+}
+
+// unused returns the numbers and names that are locked for the scope but not
+// part of the used names, sorted.
+func unused(numbers map[string]int, used map[string]struct{}) ([]int, []string) {
+	var (
+		reservedNumbers []int
+		reservedNames   []string
+	)
+
+	for name, n := range numbers {
+		if _, ok := used[name]; ok {
+			continue
+		}
+
+		reservedNumbers = append(reservedNumbers, n)
+		reservedNames = append(reservedNames, name)
+	}
+
+	sort.Ints(reservedNumbers)
+	sort.Strings(reservedNames)
+
+	return reservedNumbers, reservedNames
+}
+
+func cloneNumbers(numbers map[string]int) map[string]int {
+	cloned := make(map[string]int, len(numbers))
+	for k, v := range numbers {
+		cloned[k] = v
+	}
+
+	return cloned
+}
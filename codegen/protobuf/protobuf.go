@@ -0,0 +1,688 @@
+// Package protobuf generates proto3 messages from inferred JTD schemas.
+package protobuf
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	jtd "github.com/jsontypedef/json-typedef-go"
+
+	jtdinfer "github.com/bombsimon/jtd-infer-go"
+)
+
+// Well known types used by the generated messages.
+const (
+	timestampType   = "google.protobuf.Timestamp"
+	timestampImport = "google/protobuf/timestamp.proto"
+	valueType       = "google.protobuf.Value"
+	valueImport     = "google/protobuf/struct.proto"
+)
+
+// ErrUnknownRef is returned when a schema references a definition that doesn't
+// exist.
+var ErrUnknownRef = errors.New("unknown ref")
+
+// Options holds the options for the generated file.
+type Options struct {
+	// Package is the proto package, omitted if empty.
+	Package string
+	// Lock holds the numbers from a previous generation to keep them stable.
+	// The passed lock is never modified, the updated lock is returned in the
+	// `Result`.
+	Lock *Lock
+}
+
+// Result holds the generated proto file and the updated lock which should be
+// stored and passed to the next generation.
+type Result struct {
+	Proto string
+	Lock  *Lock
+}
+
+// Generate will generate a proto3 file with a message named after the passed
+// name. Objects are generated as nested messages, optional and nullable
+// properties are marked as `optional`, elements as `repeated`, values as
+// `map<string, T>`, enums as nested enums with an unspecified zero value and
+// discriminators as a message with a `oneof` holding a message for each
+// mapping. Nullable elements and values are wrapped in a message with an
+// optional field named value. Definitions are generated as top-level messages
+// or enums. Message and enum names are made unique within their parent by
+// adding a number.
+func Generate(name string, schema jtdinfer.Schema, opts Options) (*Result, error) {
+	lock := NewLock()
+	if opts.Lock != nil {
+		lock = opts.Lock.clone()
+	}
+
+	g := &generator{
+		lock:        lock,
+		definitions: schema.Definitions,
+		defined:     map[string]string{},
+		imports:     map[string]struct{}{},
+		root:        &message{},
+	}
+
+	rootName := g.reserve(g.root, MessageName(name))
+	if _, err := g.message(g.root, rootName, schema); err != nil {
+		return nil, err
+	}
+
+	for _, k := range sortedKeys(schema.Definitions) {
+		if !isNamed(schema.Definitions[k]) {
+			continue
+		}
+
+		if _, err := g.definition(k); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Result{
+		Proto: g.render(opts.Package),
+		Lock:  lock,
+	}, nil
+}
+
+// MessageName converts a name to a PascalCase message or enum name.
+func MessageName(name string) string {
+	var sb strings.Builder
+
+	upper := true
+
+	for _, r := range name {
+		if !isASCIILetter(r) && !isASCIIDigit(r) {
+			upper = true
+			continue
+		}
+
+		if sb.Len() == 0 && isASCIIDigit(r) {
+			sb.WriteRune('T')
+		}
+
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+
+		sb.WriteRune(r)
+	}
+
+	if sb.Len() == 0 {
+		return "T"
+	}
+
+	return sb.String()
+}
+
+// FieldName converts a name to a snake_case field name.
+func FieldName(name string) string {
+	var sb strings.Builder
+
+	for i, r := range name {
+		switch {
+		case isASCIILetter(r) || isASCIIDigit(r):
+			if unicode.IsUpper(r) && i > 0 && !strings.HasSuffix(sb.String(), "_") {
+				sb.WriteRune('_')
+			}
+
+			sb.WriteRune(unicode.ToLower(r))
+		case sb.Len() > 0 && !strings.HasSuffix(sb.String(), "_"):
+			sb.WriteRune('_')
+		}
+	}
+
+	field := strings.TrimSuffix(sb.String(), "_")
+	if field == "" || isASCIIDigit(rune(field[0])) {
+		field = "f_" + field
+	}
+
+	return field
+}
+
+type message struct {
+	name     string
+	fullName string
+	fields   []field
+	oneofs   []oneof
+	messages []*message
+	enums    []*enum
+	// types holds the names of nested messages and enums, including those
+	// reserved but not yet added.
+	types map[string]struct{}
+}
+
+// nestedName returns the fully qualified name of a type nested in the message.
+func (m *message) nestedName(name string) string {
+	if m.fullName == "" {
+		return name
+	}
+
+	return m.fullName + "." + name
+}
+
+type field struct {
+	label    string
+	typ      string
+	name     string
+	jsonName string
+	number   int
+}
+
+type oneof struct {
+	name   string
+	fields []field
+}
+
+type enum struct {
+	name     string
+	fullName string
+	values   []enumValue
+}
+
+type enumValue struct {
+	name   string
+	number int
+}
+
+type generator struct {
+	lock        *Lock
+	definitions map[string]jtdinfer.Schema
+	defined     map[string]string
+	imports     map[string]struct{}
+	root        *message
+}
+
+// definition generates the definition as a top-level message or enum the first
+// time it's referenced and returns the type name.
+func (g *generator) definition(ref string) (string, error) {
+	if typeName, ok := g.defined[ref]; ok {
+		return typeName, nil
+	}
+
+	// The name is reserved before generating the definition so it can
+	// reference itself.
+	name := g.reserve(g.root, MessageName(ref))
+	g.defined[ref] = name
+
+	definition := g.definitions[ref]
+	if definition.Enum != nil {
+		g.enum(g.root, name, definition.Enum)
+		return name, nil
+	}
+
+	if _, err := g.message(g.root, name, definition); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// reserve will reserve a unique name for a message or enum nested in the
+// parent based on the passed name.
+func (g *generator) reserve(parent *message, name string) string {
+	if parent.types == nil {
+		parent.types = map[string]struct{}{}
+	}
+
+	candidate := name
+	for n := 2; ; n++ {
+		if _, ok := parent.types[candidate]; !ok {
+			break
+		}
+
+		candidate = name + strconv.Itoa(n)
+	}
+
+	parent.types[candidate] = struct{}{}
+
+	return candidate
+}
+
+// message adds a message with the reserved name for an object or
+// discriminator schema to the parent. Other schemas are wrapped in a message
+// with a single field named value.
+func (g *generator) message(parent *message, name string, schema jtdinfer.Schema) (*message, error) {
+	m := &message{name: name, fullName: parent.nestedName(name)}
+	parent.messages = append(parent.messages, m)
+
+	switch {
+	case schema.Discriminator != "":
+		return m, g.discriminator(m, schema)
+	case schema.Properties != nil || schema.OptionalProperties != nil:
+		return m, g.properties(m, schema)
+	}
+
+	return m, g.field(m, "value", schema, false)
+}
+
+func (g *generator) properties(m *message, schema jtdinfer.Schema) error {
+	keys := make([]string, 0, len(schema.Properties)+len(schema.OptionalProperties))
+	keys = append(keys, sortedKeys(schema.Properties)...)
+	keys = append(keys, sortedKeys(schema.OptionalProperties)...)
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		property, required := schema.Properties[k]
+		if !required {
+			property = schema.OptionalProperties[k]
+		}
+
+		if err := g.field(m, k, property, !required); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *generator) discriminator(m *message, schema jtdinfer.Schema) error {
+	o := oneof{name: uniqueFieldName(m, FieldName(schema.Discriminator))}
+
+	for _, k := range sortedKeys(schema.Mapping) {
+		variantName := g.reserve(m, MessageName(k))
+		if _, err := g.message(m, variantName, schema.Mapping[k]); err != nil {
+			return err
+		}
+
+		name := uniqueFieldName(m, FieldName(k))
+		o.fields = append(o.fields, field{
+			typ:      variantName,
+			name:     name,
+			jsonName: jsonName(name, k),
+			number:   g.lock.fieldNumber(m.fullName, name),
+		})
+	}
+
+	m.oneofs = append(m.oneofs, o)
+
+	return nil
+}
+
+// field adds a field for the property to the message.
+func (g *generator) field(m *message, key string, schema jtdinfer.Schema, optional bool) error {
+	name := uniqueFieldName(m, FieldName(key))
+
+	typ, label, err := g.fieldType(m, MessageName(key), schema)
+	if err != nil {
+		return err
+	}
+
+	if label == "" && (optional || schema.Nullable) {
+		label = "optional"
+	}
+
+	m.fields = append(m.fields, field{
+		label:    label,
+		typ:      typ,
+		name:     name,
+		jsonName: jsonName(name, key),
+		number:   g.lock.fieldNumber(m.fullName, name),
+	})
+
+	return nil
+}
+
+// fieldType returns the type and label for a schema. Nested messages and enums
+// are added to the parent with a unique name based on the passed name.
+func (g *generator) fieldType(parent *message, name string, schema jtdinfer.Schema) (string, string, error) {
+	switch {
+	case schema.Ref != nil:
+		definition, ok := g.definitions[*schema.Ref]
+		if !ok {
+			return "", "", fmt.Errorf("%w: %s", ErrUnknownRef, *schema.Ref)
+		}
+
+		// Only messages and enums can be referenced by name, other definitions
+		// are inlined.
+		if !isNamed(definition) {
+			return g.fieldType(parent, name, definition)
+		}
+
+		typ, err := g.definition(*schema.Ref)
+
+		return typ, "", err
+	case schema.Type != "":
+		return g.scalar(schema.Type), "", nil
+	case schema.Enum != nil:
+		name = g.reserve(parent, name)
+		g.enum(parent, name, schema.Enum)
+
+		return name, "", nil
+	case schema.Elements != nil:
+		typ, label, err := g.fieldType(parent, name+"Element", *schema.Elements)
+		if err != nil {
+			return "", "", err
+		}
+
+		return g.wrapElement(parent, name, "List", typ, label, schema.Elements.Nullable), "repeated", nil
+	case schema.Values != nil:
+		typ, label, err := g.fieldType(parent, name+"Value", *schema.Values)
+		if err != nil {
+			return "", "", err
+		}
+
+		return "map<string, " + g.wrapElement(parent, name, "Values", typ, label, schema.Values.Nullable) + ">", "", nil
+	case schema.Properties != nil || schema.OptionalProperties != nil, schema.Discriminator != "":
+		name = g.reserve(parent, name)
+		if _, err := g.message(parent, name, schema); err != nil {
+			return "", "", err
+		}
+
+		return name, "", nil
+	}
+
+	g.imports[valueImport] = struct{}{}
+
+	return valueType, "", nil
+}
+
+// wrapElement returns the type to use for an element or value. Repeated fields
+// and maps can't be nested directly so they're wrapped in a message with a
+// single field named values and the name with the suffix. Elements and values
+// can't be null so nullable ones are wrapped in a message with an optional
+// field named value.
+func (g *generator) wrapElement(parent *message, name, suffix, typ, label string, nullable bool) string {
+	switch {
+	case label != "" || strings.HasPrefix(typ, "map<"):
+		return g.wrapper(parent, name+suffix, "values", typ, label)
+	case nullable:
+		return g.wrapper(parent, name+"Nullable", "value", typ, "optional")
+	}
+
+	return typ
+}
+
+// wrapper adds a message with a single field with the type and label.
+func (g *generator) wrapper(parent *message, name, fieldName, typ, label string) string {
+	name = g.reserve(parent, name)
+	fullName := parent.nestedName(name)
+
+	parent.messages = append(parent.messages, &message{
+		name:     name,
+		fullName: fullName,
+		fields: []field{{
+			label:  label,
+			typ:    typ,
+			name:   fieldName,
+			number: g.lock.fieldNumber(fullName, fieldName),
+		}},
+	})
+
+	return name
+}
+
+// enum adds an enum with the reserved name to the parent.
+func (g *generator) enum(parent *message, name string, values []string) {
+	fullName := parent.nestedName(name)
+
+	prefix := FieldName(name)
+	prefix = strings.ToUpper(prefix)
+
+	e := &enum{
+		name:     name,
+		fullName: fullName,
+		values:   []enumValue{{name: prefix + "_UNSPECIFIED", number: 0}},
+	}
+
+	seen := map[string]struct{}{prefix + "_UNSPECIFIED": {}}
+
+	for _, v := range sortedStrings(values) {
+		valueName := prefix + "_" + strings.ToUpper(strings.TrimPrefix(FieldName(v), "f_"))
+
+		candidate := valueName
+		for n := 2; ; n++ {
+			if _, ok := seen[candidate]; !ok {
+				break
+			}
+
+			candidate = valueName + "_" + strconv.Itoa(n)
+		}
+
+		seen[candidate] = struct{}{}
+		e.values = append(e.values, enumValue{
+			name:   candidate,
+			number: g.lock.enumNumber(fullName, candidate),
+		})
+	}
+
+	parent.enums = append(parent.enums, e)
+}
+
+func (g *generator) scalar(t jtd.Type) string {
+	switch t {
+	case jtd.TypeBoolean:
+		return "bool"
+	case jtd.TypeString:
+		return "string"
+	case jtd.TypeTimestamp:
+		g.imports[timestampImport] = struct{}{}
+		return timestampType
+	case jtd.TypeInt8, jtd.TypeInt16, jtd.TypeInt32:
+		return "int32"
+	case jtd.TypeUint8, jtd.TypeUint16, jtd.TypeUint32:
+		return "uint32"
+	case jtd.TypeFloat32:
+		return "float"
+	case jtd.TypeFloat64:
+		return "double"
+	}
+
+	g.imports[valueImport] = struct{}{}
+
+	return valueType
+}
+
+func (g *generator) render(pkg string) string {
+	var sb strings.Builder
+
+	sb.WriteString("syntax = \"proto3\";\n")
+
+	if pkg != "" {
+		fmt.Fprintf(&sb, "\npackage %s;\n", pkg)
+	}
+
+	if len(g.imports) > 0 {
+		sb.WriteString("\n")
+
+		imports := make([]string, 0, len(g.imports))
+		for k := range g.imports {
+			imports = append(imports, k)
+		}
+
+		sort.Strings(imports)
+
+		for _, v := range imports {
+			fmt.Fprintf(&sb, "import %q;\n", v)
+		}
+	}
+
+	for _, e := range g.root.enums {
+		sb.WriteString("\n")
+		g.renderEnum(&sb, e, "")
+	}
+
+	for _, m := range g.root.messages {
+		sb.WriteString("\n")
+		g.renderMessage(&sb, m, "")
+	}
+
+	return sb.String()
+}
+
+func (g *generator) renderMessage(sb *strings.Builder, m *message, indent string) {
+	fmt.Fprintf(sb, "%smessage %s {\n", indent, m.name)
+
+	inner := indent + "  "
+	used := map[string]struct{}{}
+
+	for i, e := range m.enums {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+
+		g.renderEnum(sb, e, inner)
+	}
+
+	for i, nested := range m.messages {
+		if i > 0 || len(m.enums) > 0 {
+			sb.WriteString("\n")
+		}
+
+		g.renderMessage(sb, nested, inner)
+	}
+
+	if (len(m.enums) > 0 || len(m.messages) > 0) && (len(m.fields) > 0 || len(m.oneofs) > 0) {
+		sb.WriteString("\n")
+	}
+
+	for _, f := range m.fields {
+		used[f.name] = struct{}{}
+
+		renderField(sb, f, inner)
+	}
+
+	for _, o := range m.oneofs {
+		fmt.Fprintf(sb, "%soneof %s {\n", inner, o.name)
+
+		for _, f := range o.fields {
+			used[f.name] = struct{}{}
+
+			renderField(sb, f, inner+"  ")
+		}
+
+		fmt.Fprintf(sb, "%s}\n", inner)
+	}
+
+	renderReserved(sb, g.lock.Messages[m.fullName], used, inner)
+
+	fmt.Fprintf(sb, "%s}\n", indent)
+}
+
+func (g *generator) renderEnum(sb *strings.Builder, e *enum, indent string) {
+	fmt.Fprintf(sb, "%senum %s {\n", indent, e.name)
+
+	used := map[string]struct{}{}
+
+	for _, v := range e.values {
+		used[v.name] = struct{}{}
+
+		fmt.Fprintf(sb, "%s  %s = %d;\n", indent, v.name, v.number)
+	}
+
+	renderReserved(sb, g.lock.Enums[e.fullName], used, indent+"  ")
+
+	fmt.Fprintf(sb, "%s}\n", indent)
+}
+
+func renderField(sb *strings.Builder, f field, indent string) {
+	sb.WriteString(indent)
+
+	if f.label != "" {
+		sb.WriteString(f.label + " ")
+	}
+
+	fmt.Fprintf(sb, "%s %s = %d", f.typ, f.name, f.number)
+
+	if f.jsonName != "" {
+		fmt.Fprintf(sb, " [json_name = %q]", f.jsonName)
+	}
+
+	sb.WriteString(";\n")
+}
+
+// renderReserved renders reserved numbers and names for fields that are locked
+// but no longer used.
+func renderReserved(sb *strings.Builder, numbers map[string]int, used map[string]struct{}, indent string) {
+	reservedNumbers, reservedNames := unused(numbers, used)
+	if len(reservedNumbers) == 0 {
+		return
+	}
+
+	numberList := make([]string, len(reservedNumbers))
+	for i, n := range reservedNumbers {
+		numberList[i] = strconv.Itoa(n)
+	}
+
+	nameList := make([]string, len(reservedNames))
+	for i, n := range reservedNames {
+		nameList[i] = strconv.Quote(n)
+	}
+
+	fmt.Fprintf(sb, "%sreserved %s;\n", indent, strings.Join(numberList, ", "))
+	fmt.Fprintf(sb, "%sreserved %s;\n", indent, strings.Join(nameList, ", "))
+}
+
+// uniqueFieldName returns a field name that isn't used by any other field in
+// the message.
+func uniqueFieldName(m *message, name string) string {
+	used := map[string]struct{}{}
+
+	for _, f := range m.fields {
+		used[f.name] = struct{}{}
+	}
+
+	for _, o := range m.oneofs {
+		used[o.name] = struct{}{}
+
+		for _, f := range o.fields {
+			used[f.name] = struct{}{}
+		}
+	}
+
+	candidate := name
+	for n := 2; ; n++ {
+		if _, ok := used[candidate]; !ok {
+			return candidate
+		}
+
+		candidate = name + "_" + strconv.Itoa(n)
+	}
+}
+
+// jsonName returns the original name if it differs from the field name since
+// JSON parsers only accepts the field name or the name set with `json_name`.
+func jsonName(fieldName, original string) string {
+	if fieldName == original {
+		return ""
+	}
+
+	return original
+}
+
+// isNamed returns true if the schema is generated as a message or an enum.
+func isNamed(schema jtdinfer.Schema) bool {
+	return schema.Properties != nil ||
+		schema.OptionalProperties != nil ||
+		schema.Discriminator != "" ||
+		schema.Enum != nil
+}
+
+func isASCIILetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isASCIIDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func sortedKeys(m map[string]jtdinfer.Schema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func sortedStrings(values []string) []string {
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+
+	return sorted
+}
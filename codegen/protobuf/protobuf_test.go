@@ -0,0 +1,302 @@
+package protobuf
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jtdinfer "github.com/bombsimon/jtd-infer-go"
+)
+
+func TestGenerate(t *testing.T) {
+	rows := []string{
+		`{
+			"id": 1,
+			"createdAt": "2023-01-01T00:00:00Z",
+			"name": null,
+			"status": "in-progress",
+			"address": {"city": "Stockholm"},
+			"scores": {"x": [1.5]},
+			"matrix": [[1, 2], [3, -4]],
+			"event": {"type": "click", "x": 300},
+			"anything": 1
+		}`,
+		`{
+			"id": 2,
+			"createdAt": "2023-01-01T00:00:00Z",
+			"name": "Joe",
+			"status": "done",
+			"address": {"city": "Umeå"},
+			"scores": {},
+			"matrix": [],
+			"event": {"type": "key", "key": "a"},
+			"anything": "x",
+			"extra": true
+		}`,
+	}
+
+	hints := jtdinfer.Hints{
+		Enums:         jtdinfer.NewHintSet().Add([]string{"status"}),
+		Values:        jtdinfer.NewHintSet().Add([]string{"scores"}),
+		Discriminator: jtdinfer.NewHintSet().Add([]string{"event", "type"}),
+	}
+
+	schema := jtdinfer.InferStrings(rows, hints).IntoSchema()
+
+	result, err := Generate("user", schema, Options{Package: "example.v1"})
+	require.NoError(t, err)
+
+	expected := `syntax = "proto3";
+
+package example.v1;
+
+import "google/protobuf/struct.proto";
+import "google/protobuf/timestamp.proto";
+
+message User {
+  enum Status {
+    STATUS_UNSPECIFIED = 0;
+    STATUS_DONE = 1;
+    STATUS_IN_PROGRESS = 2;
+  }
+
+  message Address {
+    string city = 1;
+  }
+
+  message Event {
+    message Click {
+      uint32 x = 1;
+    }
+
+    message Key {
+      string key = 1;
+    }
+
+    oneof type {
+      Click click = 1;
+      Key key = 2;
+    }
+  }
+
+  message MatrixList {
+    repeated int32 values = 1;
+  }
+
+  message ScoresValues {
+    repeated double values = 1;
+  }
+
+  Address address = 1;
+  google.protobuf.Value anything = 2;
+  google.protobuf.Timestamp created_at = 3 [json_name = "createdAt"];
+  Event event = 4;
+  optional bool extra = 5;
+  uint32 id = 6;
+  repeated MatrixList matrix = 7;
+  optional string name = 8;
+  map<string, ScoresValues> scores = 9;
+  Status status = 10;
+}
+`
+
+	assert.Equal(t, expected, result.Proto)
+}
+
+func TestGenerateWithLock(t *testing.T) {
+	first := jtdinfer.InferStrings([]string{`{"a": 1, "b": "x", "c": true, "kind": "x"}`}, jtdinfer.Hints{
+		Enums: jtdinfer.NewHintSet().Add([]string{"kind"}),
+	}).IntoSchema()
+
+	result, err := Generate("Row", first, Options{})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, result.Lock.Write(&buf))
+
+	lock, err := ReadLock(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, result.Lock, lock)
+
+	second := jtdinfer.InferStrings([]string{`{"aa": 1, "c": true, "b": "x", "kind": "y"}`}, jtdinfer.Hints{
+		Enums: jtdinfer.NewHintSet().Add([]string{"kind"}),
+	}).IntoSchema()
+
+	result, err = Generate("Row", second, Options{Lock: lock})
+	require.NoError(t, err)
+
+	expected := `syntax = "proto3";
+
+message Row {
+  enum Kind {
+    KIND_UNSPECIFIED = 0;
+    KIND_Y = 2;
+    reserved 1;
+    reserved "KIND_X";
+  }
+
+  uint32 aa = 5;
+  string b = 2;
+  bool c = 3;
+  Kind kind = 4;
+  reserved 1;
+  reserved "a";
+}
+`
+
+	assert.Equal(t, expected, result.Proto)
+
+	// The passed lock is not modified.
+	assert.NotContains(t, lock.Messages["Row"], "aa")
+	assert.Equal(t, 5, result.Lock.Messages["Row"]["aa"])
+}
+
+func TestGenerateDefinitions(t *testing.T) {
+	address := "address"
+	tags := "tags"
+	schema := jtdinfer.Schema{
+		Definitions: map[string]jtdinfer.Schema{
+			"address": {
+				Properties: map[string]jtdinfer.Schema{
+					"city": {Type: "string"},
+				},
+			},
+			"tags": {Elements: &jtdinfer.Schema{Type: "string"}},
+		},
+		Properties: map[string]jtdinfer.Schema{
+			"home": {Ref: &address, Nullable: true},
+			"tags": {Ref: &tags},
+		},
+	}
+
+	result, err := Generate("Person", schema, Options{})
+	require.NoError(t, err)
+
+	expected := `syntax = "proto3";
+
+message Person {
+  optional Address home = 1;
+  repeated string tags = 2;
+}
+
+message Address {
+  string city = 1;
+}
+`
+
+	assert.Equal(t, expected, result.Proto)
+
+	unknown := "missing"
+	_, err = Generate("Person", jtdinfer.Schema{
+		Properties: map[string]jtdinfer.Schema{"x": {Ref: &unknown}},
+	}, Options{})
+	require.ErrorIs(t, err, ErrUnknownRef)
+}
+
+func TestGenerateNameCollisions(t *testing.T) {
+	user := "user"
+	schema := jtdinfer.Schema{
+		Definitions: map[string]jtdinfer.Schema{
+			"user": {
+				Properties: map[string]jtdinfer.Schema{
+					"name": {Type: "string"},
+				},
+			},
+		},
+		Properties: map[string]jtdinfer.Schema{
+			"self": {Ref: &user},
+			"foo_bar": {
+				Properties: map[string]jtdinfer.Schema{"a": {Type: "string"}},
+			},
+			"fooBar": {
+				Properties: map[string]jtdinfer.Schema{"b": {Type: "string"}},
+			},
+		},
+	}
+
+	result, err := Generate("User", schema, Options{})
+	require.NoError(t, err)
+
+	expected := `syntax = "proto3";
+
+message User {
+  message FooBar {
+    string b = 1;
+  }
+
+  message FooBar2 {
+    string a = 1;
+  }
+
+  FooBar foo_bar = 1 [json_name = "fooBar"];
+  FooBar2 foo_bar_2 = 2 [json_name = "foo_bar"];
+  User2 self = 3;
+}
+
+message User2 {
+  string name = 1;
+}
+`
+
+	assert.Equal(t, expected, result.Proto)
+}
+
+func TestGenerateNullableElements(t *testing.T) {
+	schema := jtdinfer.InferStrings([]string{
+		`{"tags": ["a", null], "items": [{"id": 1}, null], "scores": {"a": 1, "b": null}}`,
+	}, jtdinfer.Hints{
+		Values: jtdinfer.NewHintSet().Add([]string{"scores"}),
+	}).IntoSchema()
+
+	result, err := Generate("Row", schema, Options{})
+	require.NoError(t, err)
+
+	expected := `syntax = "proto3";
+
+message Row {
+  message ItemsElement {
+    uint32 id = 1;
+  }
+
+  message ItemsNullable {
+    optional ItemsElement value = 1;
+  }
+
+  message ScoresNullable {
+    optional uint32 value = 1;
+  }
+
+  message TagsNullable {
+    optional string value = 1;
+  }
+
+  repeated ItemsNullable items = 1;
+  map<string, ScoresNullable> scores = 2;
+  repeated TagsNullable tags = 3;
+}
+`
+
+	assert.Equal(t, expected, result.Proto)
+}
+
+func TestNames(t *testing.T) {
+	for input, expected := range map[string]string{
+		"createdAt":  "created_at",
+		"created_at": "created_at",
+		"zip-code":   "zip_code",
+		"1st":        "f_1st",
+		"":           "f_",
+	} {
+		assert.Equal(t, expected, FieldName(input))
+	}
+
+	for input, expected := range map[string]string{
+		"user":       "User",
+		"user_event": "UserEvent",
+		"1st":        "T1st",
+	} {
+		assert.Equal(t, expected, MessageName(input))
+	}
+}
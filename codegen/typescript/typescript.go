@@ -0,0 +1,359 @@
+// Package typescript renders inferred JTD schemas as TypeScript declarations.
+package typescript
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	jtd "github.com/jsontypedef/json-typedef-go"
+
+	jtdinfer "github.com/bombsimon/jtd-infer-go"
+)
+
+// ErrUnknownRef is returned when a schema references a definition that doesn't
+// exist.
+var ErrUnknownRef = errors.New("unknown ref")
+
+// Generate will render the schema as TypeScript declarations where the root
+// type is named after the passed name converted with `TypeName`. Objects are
+// rendered as interfaces and nested objects get their own interface named after
+// the parent and the property. Each definition is rendered as a named type.
+func Generate(name string, schema jtdinfer.Schema) (string, error) {
+	g := &generator{
+		names: map[string]struct{}{},
+		refs:  map[string]string{},
+	}
+
+	definitionNames := make([]string, 0, len(schema.Definitions))
+	for k := range schema.Definitions {
+		definitionNames = append(definitionNames, k)
+	}
+
+	sort.Strings(definitionNames)
+
+	// Reserve the names for all definitions first since they can be
+	// referenced from anywhere.
+	rootName := g.reserve(TypeName(name))
+	for _, k := range definitionNames {
+		g.refs[k] = g.reserve(TypeName(k))
+	}
+
+	if err := g.declare(rootName, schema); err != nil {
+		return "", err
+	}
+
+	for _, k := range definitionNames {
+		if err := g.declare(g.refs[k], schema.Definitions[k]); err != nil {
+			return "", err
+		}
+	}
+
+	return strings.Join(g.declarations, "\n"), nil
+}
+
+// TypeName converts a name to a PascalCase TypeScript type name.
+func TypeName(name string) string {
+	var sb strings.Builder
+
+	upper := true
+
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+
+		if sb.Len() == 0 && unicode.IsDigit(r) {
+			sb.WriteRune('T')
+		}
+
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+
+		sb.WriteRune(r)
+	}
+
+	if sb.Len() == 0 {
+		return "T"
+	}
+
+	return sb.String()
+}
+
+type generator struct {
+	names map[string]struct{}
+	// refs holds the reserved type name for each definition.
+	refs         map[string]string
+	declarations []string
+}
+
+// reserve will reserve a unique type name based on the passed name.
+func (g *generator) reserve(name string) string {
+	candidate := name
+	for n := 2; ; n++ {
+		if _, ok := g.names[candidate]; !ok {
+			break
+		}
+
+		candidate = name + strconv.Itoa(n)
+	}
+
+	g.names[candidate] = struct{}{}
+
+	return candidate
+}
+
+// declare will add a declaration with the passed name for the schema.
+// The declaration is added before any declarations for nested types.
+func (g *generator) declare(name string, schema jtdinfer.Schema) error {
+	idx := len(g.declarations)
+	g.declarations = append(g.declarations, "")
+
+	if isObject(schema) && !schema.Nullable {
+		body, err := g.interfaceBody(name, schema, "")
+		if err != nil {
+			return err
+		}
+
+		g.declarations[idx] = fmt.Sprintf("export interface %s %s\n", name, body)
+
+		return nil
+	}
+
+	if schema.Discriminator != "" {
+		return g.declareDiscriminator(idx, name, schema)
+	}
+
+	expr, err := g.typeExpr(name, schema)
+	if err != nil {
+		return err
+	}
+
+	g.declarations[idx] = fmt.Sprintf("export type %s = %s;\n", name, expr)
+
+	return nil
+}
+
+func (g *generator) declareDiscriminator(idx int, name string, schema jtdinfer.Schema) error {
+	variants := make([]string, 0, len(schema.Mapping))
+
+	for _, k := range sortedKeys(schema.Mapping) {
+		variantName := g.reserve(name + TypeName(k))
+		tag := fmt.Sprintf("%s: %s;", propertyName(schema.Discriminator), quote(k))
+
+		variantIdx := len(g.declarations)
+		g.declarations = append(g.declarations, "")
+
+		body, err := g.interfaceBody(variantName, schema.Mapping[k], tag)
+		if err != nil {
+			return err
+		}
+
+		g.declarations[variantIdx] = fmt.Sprintf("export interface %s %s\n", variantName, body)
+
+		variants = append(variants, variantName)
+	}
+
+	if len(variants) == 0 {
+		variants = append(variants, "never")
+	}
+
+	if schema.Nullable {
+		variants = append(variants, "null")
+	}
+
+	g.declarations[idx] = fmt.Sprintf("export type %s = %s;\n", name, strings.Join(variants, " | "))
+
+	return nil
+}
+
+// interfaceBody renders the body of an interface for an object schema with an
+// optional extra first line.
+func (g *generator) interfaceBody(name string, schema jtdinfer.Schema, first string) (string, error) {
+	lines := []string{"{"}
+	if first != "" {
+		lines = append(lines, "  "+first)
+	}
+
+	keys := make([]string, 0, len(schema.Properties)+len(schema.OptionalProperties))
+	keys = append(keys, sortedKeys(schema.Properties)...)
+	keys = append(keys, sortedKeys(schema.OptionalProperties)...)
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		property, required := schema.Properties[k]
+		optional := ""
+
+		if !required {
+			property = schema.OptionalProperties[k]
+			optional = "?"
+		}
+
+		expr, err := g.typeExpr(name+TypeName(k), property)
+		if err != nil {
+			return "", err
+		}
+
+		lines = append(lines, fmt.Sprintf("  %s%s: %s;", propertyName(k), optional, expr))
+	}
+
+	if schema.AdditionalProperties {
+		lines = append(lines, "  [key: string]: unknown;")
+	}
+
+	lines = append(lines, "}")
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// typeExpr returns the type expression for a schema. Objects and
+// discriminators are declared as named types with the passed name.
+func (g *generator) typeExpr(name string, schema jtdinfer.Schema) (string, error) {
+	expr, err := g.nonNullableTypeExpr(name, schema)
+	if err != nil {
+		return "", err
+	}
+
+	if schema.Nullable && expr != "unknown" {
+		return expr + " | null", nil
+	}
+
+	return expr, nil
+}
+
+func (g *generator) nonNullableTypeExpr(name string, schema jtdinfer.Schema) (string, error) {
+	switch {
+	case schema.Ref != nil:
+		typeName, ok := g.refs[*schema.Ref]
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrUnknownRef, *schema.Ref)
+		}
+
+		return typeName, nil
+	case schema.Type != "":
+		return primitive(schema.Type), nil
+	case len(schema.Enum) > 0:
+		enum := make([]string, len(schema.Enum))
+		for i, v := range schema.Enum {
+			enum[i] = quote(v)
+		}
+
+		sort.Strings(enum)
+
+		return strings.Join(enum, " | "), nil
+	case schema.Elements != nil:
+		elements, err := g.typeExpr(name+"Element", *schema.Elements)
+		if err != nil {
+			return "", err
+		}
+
+		if strings.Contains(elements, " | ") {
+			return "(" + elements + ")[]", nil
+		}
+
+		return elements + "[]", nil
+	case schema.Values != nil:
+		values, err := g.typeExpr(name+"Value", *schema.Values)
+		if err != nil {
+			return "", err
+		}
+
+		return "Record<string, " + values + ">", nil
+	case isObject(schema), schema.Discriminator != "":
+		schema.Nullable = false
+		typeName := g.reserve(name)
+
+		if err := g.declare(typeName, schema); err != nil {
+			return "", err
+		}
+
+		return typeName, nil
+	}
+
+	return "unknown", nil
+}
+
+func primitive(t jtd.Type) string {
+	switch t {
+	case jtd.TypeBoolean:
+		return "boolean"
+	case jtd.TypeString, jtd.TypeTimestamp:
+		return "string"
+	case jtd.TypeFloat32, jtd.TypeFloat64,
+		jtd.TypeInt8, jtd.TypeUint8,
+		jtd.TypeInt16, jtd.TypeUint16,
+		jtd.TypeInt32, jtd.TypeUint32:
+		return "number"
+	}
+
+	return "unknown"
+}
+
+func isObject(schema jtdinfer.Schema) bool {
+	return schema.Properties != nil || schema.OptionalProperties != nil
+}
+
+// propertyName returns the property name, quoted if it's not a valid
+// identifier.
+func propertyName(name string) string {
+	for i, r := range name {
+		isValid := r == '_' || r == '$' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))
+		if !isValid {
+			return quote(name)
+		}
+	}
+
+	if name == "" {
+		return `""`
+	}
+
+	return name
+}
+
+// quote returns the value as a double quoted string literal. Unlike
+// `strconv.Quote` only escapes valid in TypeScript are used, non printable
+// characters are escaped as `\u{...}`.
+func quote(value string) string {
+	var sb strings.Builder
+
+	sb.WriteByte('"')
+
+	for _, r := range value {
+		switch {
+		case r == '"' || r == '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\r':
+			sb.WriteString(`\r`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case r == '\u2028' || r == '\u2029' || !unicode.IsPrint(r):
+			sb.WriteString(`\u{` + strconv.FormatInt(int64(r), 16) + `}`)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+
+	sb.WriteByte('"')
+
+	return sb.String()
+}
+
+func sortedKeys(m map[string]jtdinfer.Schema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,180 @@
+package typescript
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jtdinfer "github.com/bombsimon/jtd-infer-go"
+)
+
+func TestGenerate(t *testing.T) {
+	rows := []string{
+		`{
+			"id": 1,
+			"name": "Joe",
+			"created_at": "2023-01-01T00:00:00Z",
+			"address": {"city": "Stockholm", "zip-code": "111 22"},
+			"tags": ["a", null],
+			"scores": {"x": 1.5},
+			"status": "active",
+			"events": [{"type": "click", "x": 1, "y": 2}, {"type": "key", "key": "a"}],
+			"nickname": null
+		}`,
+		`{
+			"id": 2,
+			"name": "Jane",
+			"created_at": "2023-01-02T00:00:00Z",
+			"address": null,
+			"tags": [],
+			"scores": {},
+			"status": "inactive",
+			"events": [],
+			"nickname": "JJ",
+			"extra": true
+		}`,
+	}
+
+	hints := jtdinfer.Hints{
+		Enums:         jtdinfer.NewHintSet().Add([]string{"status"}),
+		Values:        jtdinfer.NewHintSet().Add([]string{"scores"}),
+		Discriminator: jtdinfer.NewHintSet().Add([]string{"events", "-", "type"}),
+	}
+
+	schema := jtdinfer.InferStrings(rows, hints).IntoSchema()
+
+	got, err := Generate("user", schema)
+	require.NoError(t, err)
+
+	expected := `export interface User {
+  address: UserAddress | null;
+  created_at: string;
+  events: UserEventsElement[];
+  extra?: boolean;
+  id: number;
+  name: string;
+  nickname: string | null;
+  scores: Record<string, number>;
+  status: "active" | "inactive";
+  tags: (string | null)[];
+}
+
+export interface UserAddress {
+  city: string;
+  "zip-code": string;
+}
+
+export type UserEventsElement = UserEventsElementClick | UserEventsElementKey;
+
+export interface UserEventsElementClick {
+  type: "click";
+  x: number;
+  y: number;
+}
+
+export interface UserEventsElementKey {
+  type: "key";
+  key: string;
+}
+`
+
+	assert.Equal(t, expected, got)
+}
+
+func TestGenerateDefinitions(t *testing.T) {
+	ref := "address"
+	schema := jtdinfer.Schema{
+		Definitions: map[string]jtdinfer.Schema{
+			"address": {
+				Properties: map[string]jtdinfer.Schema{
+					"city": {Type: "string"},
+				},
+			},
+			"any": {},
+		},
+		Properties: map[string]jtdinfer.Schema{
+			"home": {Ref: &ref, Nullable: true},
+		},
+	}
+
+	got, err := Generate("Person", schema)
+	require.NoError(t, err)
+
+	expected := `export interface Person {
+  home: Address | null;
+}
+
+export interface Address {
+  city: string;
+}
+
+export type Any = unknown;
+`
+
+	assert.Equal(t, expected, got)
+
+	unknownRef := "missing"
+	_, err = Generate("Person", jtdinfer.Schema{Ref: &unknownRef})
+	require.ErrorIs(t, err, ErrUnknownRef)
+}
+
+func TestGenerateDefinitionNameCollisions(t *testing.T) {
+	user, fooBar, fooBarCamel := "user", "foo_bar", "fooBar"
+	schema := jtdinfer.Schema{
+		Definitions: map[string]jtdinfer.Schema{
+			"user":    {Type: "string"},
+			"foo_bar": {Type: "boolean"},
+			"fooBar":  {Type: "uint8"},
+		},
+		Properties: map[string]jtdinfer.Schema{
+			"a": {Ref: &user},
+			"b": {Ref: &fooBar},
+			"c": {Ref: &fooBarCamel},
+		},
+	}
+
+	got, err := Generate("User", schema)
+	require.NoError(t, err)
+
+	expected := `export interface User {
+  a: User2;
+  b: FooBar2;
+  c: FooBar;
+}
+
+export type FooBar = number;
+
+export type FooBar2 = boolean;
+
+export type User2 = string;
+`
+
+	assert.Equal(t, expected, got)
+}
+
+func TestTypeName(t *testing.T) {
+	for input, expected := range map[string]string{
+		"user":       "User",
+		"user_event": "UserEvent",
+		"zip-code":   "ZipCode",
+		"1st":        "T1st",
+		"":           "T",
+	} {
+		assert.Equal(t, expected, TypeName(input))
+	}
+}
+
+func TestQuote(t *testing.T) {
+	for input, expected := range map[string]string{
+		"plain":          `"plain"`,
+		`say "hi"\`:      `"say \"hi\"\\"`,
+		"line\nbreak\t!": `"line\nbreak\t!"`,
+		"bell\a":         `"bell\u{7}"`,
+		"\U000E0001":     `"\u{e0001}"`,
+		"\u2028":         `"\u{2028}"`,
+		"smörgås 🎉":      `"smörgås 🎉"`,
+	} {
+		assert.Equal(t, expected, quote(input))
+	}
+}
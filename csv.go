@@ -0,0 +1,371 @@
+package jtdinfer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EmptyCellPolicy decides how empty CSV cells are inferred.
+type EmptyCellPolicy uint8
+
+// Available empty cell policies.
+const (
+	// EmptyCellNull infers empty cells as null which makes the column
+	// nullable. This is the default.
+	EmptyCellNull EmptyCellPolicy = iota
+	// EmptyCellOmit omits the column from the row which makes the column
+	// optional.
+	EmptyCellOmit
+)
+
+// CSVOptions configures how CSV is parsed.
+type CSVOptions struct {
+	// Delimiter separates the fields, defaults to a comma. Use '\t' for TSV.
+	Delimiter rune
+	// Quote is used to quote fields, defaults to a double quote. A quote
+	// inside a quoted field is escaped by doubling it.
+	Quote rune
+	// NoHeader is set if the first row isn't a header. The columns are named
+	// `column1`, `column2` and so on.
+	NoHeader bool
+	// EmptyCells decides how empty cells are inferred.
+	EmptyCells EmptyCellPolicy
+}
+
+// CSVError is returned when the CSV can't be parsed.
+type CSVError struct {
+	Line int
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *CSVError) Error() string {
+	return fmt.Sprintf("jtdinfer: invalid CSV at line %d: %s", e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *CSVError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	errCSVUnterminatedQuote = errors.New("unterminated quoted field")
+	errCSVBareQuote         = errors.New("unexpected character after quoted field")
+	errCSVFieldCount        = errors.New("wrong number of fields")
+	errCSVDuplicateColumn   = errors.New("duplicate column")
+)
+
+// columnKind is the type detected for a column where each kind can be widened
+// to a string. Integers can also be widened to floats.
+type columnKind uint8
+
+const (
+	columnKindUnknown columnKind = iota
+	columnKindBoolean
+	columnKindInteger
+	columnKindFloat
+	columnKindTimestamp
+	columnKindString
+)
+
+// InferCSV will infer each row in the CSV as an object keyed by the header.
+// See `InferCSVContext` for details.
+func InferCSV(r io.Reader, opts CSVOptions, hints Hints) (*Inferrer, error) {
+	return InferCSVContext(context.Background(), r, opts, hints)
+}
+
+// InferCSVContext will infer each row in the CSV as an object keyed by the
+// header. Since all values in CSV are text the type of each column is detected
+// from all rows before inferring, a column where all cells are booleans,
+// integers, floats or RFC 3339 timestamps is inferred as such and every other
+// column as a string. Columns with an enum hint are always kept as strings. All
+// rows are read into memory to detect the column types.
+func InferCSVContext(ctx context.Context, r io.Reader, opts CSVOptions, hints Hints) (*Inferrer, error) {
+	if opts.Delimiter == 0 {
+		opts.Delimiter = ','
+	}
+
+	if opts.Quote == 0 {
+		opts.Quote = '"'
+	}
+
+	reader := &csvReader{
+		r:         bufio.NewReader(r),
+		delimiter: opts.Delimiter,
+		quote:     opts.Quote,
+	}
+
+	records, lines, err := reader.readAll()
+	if err != nil {
+		return NewInferrer(hints), err
+	}
+
+	var header []string
+
+	if !opts.NoHeader && len(records) > 0 {
+		header, records, lines = records[0], records[1:], lines[1:]
+	}
+
+	if opts.NoHeader && len(records) > 0 {
+		header = make([]string, len(records[0]))
+		for i := range header {
+			header[i] = "column" + strconv.Itoa(i+1)
+		}
+	}
+
+	seen := make(map[string]struct{}, len(header))
+	for _, name := range header {
+		if _, ok := seen[name]; ok {
+			return NewInferrer(hints), &CSVError{Line: 1, Err: fmt.Errorf("%w: %s", errCSVDuplicateColumn, name)}
+		}
+
+		seen[name] = struct{}{}
+	}
+
+	kinds := make([]columnKind, len(header))
+	for i, name := range header {
+		if hints.SubHints(name).IsEnumActive() {
+			kinds[i] = columnKindString
+		}
+	}
+
+	for n, record := range records {
+		if len(record) != len(header) {
+			return NewInferrer(hints), &CSVError{
+				Line: lines[n],
+				Err:  fmt.Errorf("%w: got %d, expected %d", errCSVFieldCount, len(record), len(header)),
+			}
+		}
+
+		for i, cell := range record {
+			if cell != "" {
+				kinds[i] = kinds[i].widen(detectColumnKind(cell))
+			}
+		}
+	}
+
+	inferrer := NewInferrer(hints)
+
+	for _, record := range records {
+		row := make(map[string]any, len(header))
+
+		for i, cell := range record {
+			if cell == "" && opts.EmptyCells == EmptyCellOmit {
+				continue
+			}
+
+			row[header[i]] = kinds[i].value(cell)
+		}
+
+		inferrer, err = inferrer.InferContext(ctx, row)
+		if err != nil {
+			return inferrer, err
+		}
+	}
+
+	return inferrer, nil
+}
+
+// detectColumnKind returns the most specific kind for the cell.
+func detectColumnKind(cell string) columnKind {
+	if strings.EqualFold(cell, "true") || strings.EqualFold(cell, "false") {
+		return columnKindBoolean
+	}
+
+	// Only numbers valid in JSON are numbers so values such as zip codes with
+	// leading zeros are kept as strings. Whitespace is allowed around JSON
+	// values but not in numbers so padded cells are kept as strings too.
+	var n json.Number
+	if cell[0] != '"' && cell == strings.TrimSpace(cell) && json.Unmarshal([]byte(cell), &n) == nil {
+		if strings.ContainsAny(cell, ".eE") {
+			return columnKindFloat
+		}
+
+		return columnKindInteger
+	}
+
+	if _, err := time.Parse(time.RFC3339, cell); err == nil {
+		return columnKindTimestamp
+	}
+
+	return columnKindString
+}
+
+// widen returns the kind that can represent both kinds.
+func (k columnKind) widen(other columnKind) columnKind {
+	switch {
+	case k == other || other == columnKindUnknown:
+		return k
+	case k == columnKindUnknown:
+		return other
+	case k == columnKindInteger && other == columnKindFloat,
+		k == columnKindFloat && other == columnKindInteger:
+		return columnKindFloat
+	}
+
+	return columnKindString
+}
+
+// value converts the cell to the value to infer for the kind.
+func (k columnKind) value(cell string) any {
+	if cell == "" {
+		return nil
+	}
+
+	switch k {
+	case columnKindBoolean:
+		return strings.EqualFold(cell, "true")
+	case columnKindInteger, columnKindFloat:
+		return json.Number(cell)
+	case columnKindUnknown, columnKindTimestamp, columnKindString:
+	}
+
+	return cell
+}
+
+// csvReader reads CSV records with a configurable delimiter and quote.
+type csvReader struct {
+	r         *bufio.Reader
+	delimiter rune
+	quote     rune
+	line      int
+}
+
+// readAll reads all records and the line each record started at. Empty lines
+// are skipped.
+func (c *csvReader) readAll() ([][]string, []int, error) {
+	var (
+		records [][]string
+		lines   []int
+	)
+
+	for {
+		record, line, err := c.read()
+		if errors.Is(err, io.EOF) {
+			return records, lines, nil
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if record == nil {
+			continue
+		}
+
+		records = append(records, record)
+		lines = append(lines, line)
+	}
+}
+
+// read reads the next record and returns the line it started at. A nil record
+// is returned for empty lines.
+func (c *csvReader) read() ([]string, int, error) {
+	if _, err := c.r.Peek(1); err != nil {
+		return nil, 0, err
+	}
+
+	c.line++
+	start := c.line
+
+	var (
+		fields    []string
+		field     strings.Builder
+		quoted    bool
+		wasQuoted bool
+	)
+
+	endField := func() {
+		fields = append(fields, field.String())
+		field.Reset()
+
+		wasQuoted = false
+	}
+
+	for {
+		r, _, err := c.r.ReadRune()
+		if errors.Is(err, io.EOF) {
+			if quoted {
+				return nil, start, &CSVError{Line: start, Err: errCSVUnterminatedQuote}
+			}
+
+			break
+		}
+
+		if err != nil {
+			return nil, start, err
+		}
+
+		if quoted {
+			if r != c.quote {
+				if r == '\n' {
+					c.line++
+				}
+
+				field.WriteRune(r)
+
+				continue
+			}
+
+			// A doubled quote is an escaped quote, anything else ends the
+			// quoted field.
+			if next, _, err := c.r.ReadRune(); err == nil {
+				if next == c.quote {
+					field.WriteRune(r)
+					continue
+				}
+
+				_ = c.r.UnreadRune()
+			}
+
+			quoted = false
+
+			continue
+		}
+
+		if r == '\r' {
+			if next, _, err := c.r.ReadRune(); err == nil && next != '\n' {
+				_ = c.r.UnreadRune()
+			}
+
+			r = '\n'
+		}
+
+		if r == '\n' {
+			break
+		}
+
+		if r == c.delimiter {
+			endField()
+			continue
+		}
+
+		if wasQuoted {
+			return nil, start, &CSVError{Line: c.line, Err: errCSVBareQuote}
+		}
+
+		if r == c.quote && field.Len() == 0 {
+			quoted = true
+			wasQuoted = true
+
+			continue
+		}
+
+		field.WriteRune(r)
+	}
+
+	if fields == nil && field.Len() == 0 && !wasQuoted {
+		return nil, start, nil
+	}
+
+	endField()
+
+	return fields, start, nil
+}
@@ -0,0 +1,140 @@
+package jtdinfer
+
+import (
+	"strings"
+	"testing"
+
+	jtd "github.com/jsontypedef/json-typedef-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInferCSV(t *testing.T) {
+	input := strings.Join([]string{
+		`id,name,active,score,created,zip,status,note`,
+		`1,Joe,true,1,2023-01-01T00:00:00Z,01234,new,`,
+		`2,"Doe, ""Jane""",FALSE,1.5,2023-01-02T00:00:00Z,12345,done,"multi`,
+		`line"`,
+		``,
+		`3,Bob,false,,2023-01-03T00:00:00Z,x,new,text`,
+	}, "\r\n")
+
+	hints := Hints{
+		Enums: NewHintSet().Add([]string{"status"}),
+	}
+
+	for _, tc := range []struct {
+		description string
+		policy      EmptyCellPolicy
+		score       func(Schema) Schema
+	}{
+		{
+			description: "empty as null",
+			policy:      EmptyCellNull,
+			score: func(s Schema) Schema {
+				s.Nullable = true
+				return s
+			},
+		},
+		{
+			description: "empty as omitted",
+			policy:      EmptyCellOmit,
+		},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			inferrer, err := InferCSV(strings.NewReader(input), CSVOptions{EmptyCells: tc.policy}, hints)
+			require.NoError(t, err)
+
+			schema := inferrer.IntoSchema()
+
+			expected := map[string]Schema{
+				"id":      {Type: jtd.TypeUint8},
+				"name":    {Type: jtd.TypeString},
+				"active":  {Type: jtd.TypeBoolean},
+				"created": {Type: jtd.TypeTimestamp},
+				"zip":     {Type: jtd.TypeString},
+			}
+
+			for k, v := range expected {
+				assert.Equal(t, v, schema.Properties[k], k)
+			}
+
+			assert.ElementsMatch(t, []string{"new", "done"}, schema.Properties["status"].Enum)
+
+			if tc.policy == EmptyCellNull {
+				assert.Equal(t, Schema{Type: jtd.TypeFloat64, Nullable: true}, schema.Properties["score"])
+				assert.Equal(t, Schema{Type: jtd.TypeString, Nullable: true}, schema.Properties["note"])
+			} else {
+				assert.Equal(t, Schema{Type: jtd.TypeFloat64}, schema.OptionalProperties["score"])
+				assert.Equal(t, Schema{Type: jtd.TypeString}, schema.OptionalProperties["note"])
+			}
+		})
+	}
+}
+
+func TestInferCSVOptions(t *testing.T) {
+	input := "1\t'a\tb'\n2\t'it''s'\n"
+
+	inferrer, err := InferCSV(strings.NewReader(input), CSVOptions{
+		Delimiter: '\t',
+		Quote:     '\'',
+		NoHeader:  true,
+	}, Hints{Examples: ExampleOptions{Size: 2}})
+	require.NoError(t, err)
+
+	schema := inferrer.IntoSchema()
+	assert.Equal(t, jtd.Type(jtd.TypeUint8), schema.Properties["column1"].Type)
+	assert.Equal(t, []any{"a\tb", "it's"}, schema.Properties["column2"].Metadata[MetadataExamples])
+}
+
+func TestInferCSVPaddedNumbers(t *testing.T) {
+	input := "a,b,c\n 12,12,1.5 \n3,4,2\n"
+
+	inferrer, err := InferCSV(strings.NewReader(input), CSVOptions{}, WithoutHints())
+	require.NoError(t, err)
+
+	assert.Equal(t, Schema{
+		Properties: map[string]Schema{
+			"a": {Type: jtd.TypeString},
+			"b": {Type: jtd.TypeUint8},
+			"c": {Type: jtd.TypeString},
+		},
+	}, inferrer.IntoSchema())
+}
+
+func TestInferCSVErrors(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		input       string
+		err         string
+	}{
+		{
+			description: "wrong number of fields",
+			input:       "a,b\n1,2\n\n1\n",
+			err:         "jtdinfer: invalid CSV at line 4: wrong number of fields: got 1, expected 2",
+		},
+		{
+			description: "unterminated quote",
+			input:       "a,b\n1,\"2\n",
+			err:         "jtdinfer: invalid CSV at line 2: unterminated quoted field",
+		},
+		{
+			description: "character after quote",
+			input:       "a,b\n1,\"2\"x\n",
+			err:         "jtdinfer: invalid CSV at line 2: unexpected character after quoted field",
+		},
+		{
+			description: "duplicate column",
+			input:       "a,a\n1,2\n",
+			err:         "jtdinfer: invalid CSV at line 1: duplicate column: a",
+		},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			_, err := InferCSV(strings.NewReader(tc.input), CSVOptions{}, WithoutHints())
+
+			var csvErr *CSVError
+			require.ErrorAs(t, err, &csvErr)
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
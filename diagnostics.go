@@ -0,0 +1,232 @@
+package jtdinfer
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// DiagnosticKind is the kind of issue found in the input that makes it
+// ambiguous under JTD.
+type DiagnosticKind uint8
+
+// Available diagnostic kinds.
+const (
+	// DiagnosticDuplicateKey is a key that occurs more than once in the same
+	// JSON object. Only the last value is inferred.
+	DiagnosticDuplicateKey DiagnosticKind = iota + 1
+	// DiagnosticInvalidUTF8 is a string or key with bytes that aren't valid
+	// UTF-8. Invalid bytes in JSON are inferred as U+FFFD.
+	DiagnosticInvalidUTF8
+	// DiagnosticImpreciseNumber is a number that can't be represented as a
+	// `float64` without losing precision.
+	DiagnosticImpreciseNumber
+	// DiagnosticDiscriminatorCollision is a key that is the tag of an active
+	// discriminator hint but which value isn't a string, so the object can't
+	// be inferred as a discriminator.
+	DiagnosticDiscriminatorCollision
+)
+
+func (k DiagnosticKind) String() string {
+	switch k {
+	case DiagnosticDuplicateKey:
+		return "duplicate key"
+	case DiagnosticInvalidUTF8:
+		return "invalid UTF-8"
+	case DiagnosticImpreciseNumber:
+		return "imprecise number"
+	case DiagnosticDiscriminatorCollision:
+		return "discriminator collision"
+	}
+
+	return "unknown"
+}
+
+// Diagnostic is the number of times an issue of a kind was found at a JSON
+// Pointer. The pointer is relative to each inferred value.
+type Diagnostic struct {
+	Pointer string
+	Kind    DiagnosticKind
+	Count   int
+}
+
+type diagnosticKey struct {
+	pointer string
+	kind    DiagnosticKind
+}
+
+// diagnostics holds the number of issues found per pointer and kind. It's
+// never changed once added to an `Inferrer`.
+type diagnostics map[diagnosticKey]int
+
+// addDiagnostic counts an issue of the kind at the pointer.
+func (s *inferState) addDiagnostic(pointer string, kind DiagnosticKind) {
+	if s.diagnostics == nil {
+		s.diagnostics = diagnostics{}
+	}
+
+	s.diagnostics[diagnosticKey{pointer: pointer, kind: kind}]++
+}
+
+// merge returns new diagnostics with the counts from both.
+func (d diagnostics) merge(other diagnostics) diagnostics {
+	if len(other) == 0 {
+		return d
+	}
+
+	if len(d) == 0 {
+		return other
+	}
+
+	merged := make(diagnostics, len(d)+len(other))
+	for k, v := range d {
+		merged[k] = v
+	}
+
+	for k, v := range other {
+		merged[k] += v
+	}
+
+	return merged
+}
+
+// list returns the diagnostics sorted by pointer and kind.
+func (d diagnostics) list() []Diagnostic {
+	list := make([]Diagnostic, 0, len(d))
+	for k, v := range d {
+		list = append(list, Diagnostic{Pointer: k.pointer, Kind: k.kind, Count: v})
+	}
+
+	sort.Slice(list, func(a, b int) bool {
+		if list[a].Pointer != list[b].Pointer {
+			return list[a].Pointer < list[b].Pointer
+		}
+
+		return list[a].Kind < list[b].Kind
+	})
+
+	return list
+}
+
+// pointerEscaper escapes JSON Pointer reference tokens where `~` must be
+// replaced before `/`.
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// formatPointer returns the path as a JSON Pointer.
+func formatPointer(path []string) string {
+	var sb strings.Builder
+
+	for _, key := range path {
+		sb.WriteByte('/')
+		sb.WriteString(pointerEscaper.Replace(key))
+	}
+
+	return sb.String()
+}
+
+// diagnosing returns true if diagnostics should be recorded.
+func (h Hints) diagnosing() bool {
+	return h.Diagnose && h.state != nil
+}
+
+// diagnose records a diagnostic at the current path.
+func (h Hints) diagnose(kind DiagnosticKind) {
+	h.state.addDiagnostic(formatPointer(h.path), kind)
+}
+
+// diagnoseValue records diagnostics for strings and numbers.
+func (h Hints) diagnoseValue(value any) {
+	switch v := value.(type) {
+	case string:
+		if !utf8.ValidString(v) {
+			h.diagnose(DiagnosticInvalidUTF8)
+		}
+	case float64, float32, nil, bool:
+	default:
+		if !isFloat64(value) {
+			h.diagnose(DiagnosticImpreciseNumber)
+		}
+	}
+}
+
+// diagnoseTag records a collision if the object has the tag but it isn't a
+// string.
+func (h Hints) diagnoseTag(o object, tag string) {
+	if _, ok := o.get(tag); ok {
+		h.SubHints(tag).diagnose(DiagnosticDiscriminatorCollision)
+	}
+}
+
+// isFloat64 returns false if the value is a number that can't be represented
+// as a `float64` without losing precision. Decimals are compared with the
+// shortest representation of the `float64`, so `0.1` is precise.
+func isFloat64(value any) bool {
+	if s, ok := value.(json.Number); ok && strings.ContainsAny(string(s), ".eE") {
+		return isFloat64Decimal(string(s))
+	}
+
+	n, ok := anyAsNumber(value)
+	if !ok {
+		return true
+	}
+
+	if n.isSmall {
+		abs := uint64(n.small)
+		if n.small < 0 {
+			abs = -abs
+		}
+
+		// A `float64` has 53 significant bits.
+		return abs == 0 || bits.Len64(abs)-bits.TrailingZeros64(abs) <= 53
+	}
+
+	if n.integer != nil {
+		_, accuracy := new(big.Float).SetInt(n.integer).Float64()
+		return accuracy == big.Exact
+	}
+
+	if f, ok := value.(*big.Float); ok {
+		_, accuracy := f.Float64()
+		return accuracy == big.Exact
+	}
+
+	return true
+}
+
+// isFloat64Decimal returns true if the decimal literal is the same as the
+// shortest representation of the `float64` it's parsed as.
+func isFloat64Decimal(s string) bool {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return false
+	}
+
+	mantissa, _, _ := strings.Cut(strings.ToLower(s), "e")
+	digits := 0
+
+	for _, c := range mantissa {
+		if c >= '1' && c <= '9' || (c == '0' && digits > 0) {
+			digits++
+		}
+	}
+
+	// Any decimal with at most 15 significant digits is the shortest
+	// representation of its `float64` unless it's subnormal.
+	if digits == 0 || (digits <= 15 && math.Abs(f) >= math.SmallestNonzeroFloat64*(1<<52)) {
+		return true
+	}
+
+	exact, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return false
+	}
+
+	shortest, _ := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+
+	return exact.Cmp(shortest) == 0
+}
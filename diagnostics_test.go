@@ -0,0 +1,185 @@
+package jtdinfer
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnostics(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		rows        []string
+		hints       Hints
+		expected    []Diagnostic
+	}{
+		{
+			description: "duplicate keys",
+			rows:        []string{`{"a": 1, "a": 2, "b": {"c": 1, "c": 2, "c": 3}}`, `{"a": 1, "a": 2}`},
+			expected: []Diagnostic{
+				{Pointer: "/a", Kind: DiagnosticDuplicateKey, Count: 2},
+				{Pointer: "/b/c", Kind: DiagnosticDuplicateKey, Count: 2},
+			},
+		},
+		{
+			description: "invalid UTF-8",
+			rows:        []string{"{\"a\": \"\xff\", \"\xfe\": 1, \"b\": [\"x\", \"\\u00e5\xc3\"]}"},
+			expected: []Diagnostic{
+				{Pointer: "/a", Kind: DiagnosticInvalidUTF8, Count: 1},
+				{Pointer: "/b/1", Kind: DiagnosticInvalidUTF8, Count: 1},
+				{Pointer: "/\ufffd", Kind: DiagnosticInvalidUTF8, Count: 1},
+			},
+		},
+		{
+			description: "imprecise numbers",
+			rows: []string{
+				`{"a": 9007199254740993, "b": 9007199254740992, "c": 0.1, "d": 1.00000000000000000001, "e": 1e400}`,
+				`{"f": 123456789012345678901234567890, "g": -1.5e-3, "h": 1.50000000000000000000, "i": 5e-324, "j": 1e-400}`,
+			},
+			expected: []Diagnostic{
+				{Pointer: "/a", Kind: DiagnosticImpreciseNumber, Count: 1},
+				{Pointer: "/d", Kind: DiagnosticImpreciseNumber, Count: 1},
+				{Pointer: "/e", Kind: DiagnosticImpreciseNumber, Count: 1},
+				{Pointer: "/f", Kind: DiagnosticImpreciseNumber, Count: 1},
+				{Pointer: "/j", Kind: DiagnosticImpreciseNumber, Count: 1},
+			},
+		},
+		{
+			description: "discriminator collision",
+			rows: []string{
+				`{"e": {"type": 1, "x": 1}, "f": {"x": 1}}`,
+				`{"e": {"type": "a"}, "f": {"type": "b"}}`,
+			},
+			hints: Hints{
+				Discriminator: NewHintSet().Add([]string{"-", "type"}),
+			},
+			expected: []Diagnostic{
+				{Pointer: "/e/type", Kind: DiagnosticDiscriminatorCollision, Count: 1},
+			},
+		},
+		{
+			description: "discriminator collision after inferred",
+			rows: []string{
+				`{"type": "a"}`,
+				`{"type": true}`,
+				`{"type": null}`,
+			},
+			hints: Hints{
+				Discriminator: NewHintSet().Add([]string{"type"}),
+			},
+			expected: []Diagnostic{
+				{Pointer: "/type", Kind: DiagnosticDiscriminatorCollision, Count: 1},
+			},
+		},
+		{
+			description: "escaped pointer",
+			rows:        []string{`{"a/b": {"~": 1e400}}`},
+			expected: []Diagnostic{
+				{Pointer: "/a~1b/~0", Kind: DiagnosticImpreciseNumber, Count: 1},
+			},
+		},
+		{
+			description: "root",
+			rows:        []string{"\"\xff\"", `1e400`},
+			expected: []Diagnostic{
+				{Pointer: "", Kind: DiagnosticInvalidUTF8, Count: 1},
+				{Pointer: "", Kind: DiagnosticImpreciseNumber, Count: 1},
+			},
+		},
+		{
+			description: "clean",
+			rows:        []string{`{"a": [1, 2.5, "x"], "b": {"c": null}}`},
+			expected:    []Diagnostic{},
+		},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			hints := tc.hints
+			hints.Diagnose = true
+
+			inferrer, err := InferStringsContext(context.Background(), tc.rows, hints)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, inferrer.Diagnostics())
+
+			reader, err := InferReader(strings.NewReader(strings.Join(tc.rows, "\n")), ReaderOptions{}, hints)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, reader.Diagnostics())
+
+			withoutDiagnose, err := InferStringsContext(context.Background(), tc.rows, tc.hints)
+			require.NoError(t, err)
+			assert.Empty(t, withoutDiagnose.Diagnostics())
+		})
+	}
+}
+
+func TestDiagnosticsValues(t *testing.T) {
+	inferrer := NewInferrer(Hints{Diagnose: true}).
+		Infer(map[string]any{"a": nil, "b": uint64(math.MaxUint64)}).
+		Infer(map[string]any{
+			"a":    "\xff",
+			"\xfe": []any{big.NewInt(1), new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), 80), big.NewInt(1))},
+		})
+
+	assert.Equal(t, []Diagnostic{
+		{Pointer: "/a", Kind: DiagnosticInvalidUTF8, Count: 1},
+		{Pointer: "/b", Kind: DiagnosticImpreciseNumber, Count: 1},
+		{Pointer: "/\xfe", Kind: DiagnosticInvalidUTF8, Count: 1},
+		{Pointer: "/\xfe/1", Kind: DiagnosticImpreciseNumber, Count: 1},
+	}, inferrer.Diagnostics())
+
+	precise := NewInferrer(Hints{Diagnose: true}).
+		Infer(map[string]any{
+			"a": uint64(1 << 63),
+			"b": big.NewFloat(0.5),
+			"c": int64(-1 << 53),
+			"d": new(big.Int).Lsh(big.NewInt(3), 80),
+		})
+	assert.Empty(t, precise.Diagnostics())
+}
+
+func TestDiagnosticsCheckpoint(t *testing.T) {
+	hints := Hints{Diagnose: true}
+
+	first := NewInferrer(hints).InferJSON([]byte(`{"a": 1, "a": 2}`))
+	second := first.InferJSON([]byte(`{"b": 1e400}`))
+
+	assert.Equal(t, []Diagnostic{
+		{Pointer: "/a", Kind: DiagnosticDuplicateKey, Count: 1},
+	}, first.Diagnostics())
+
+	assert.Equal(t, []Diagnostic{
+		{Pointer: "/a", Kind: DiagnosticDuplicateKey, Count: 2},
+		{Pointer: "/b", Kind: DiagnosticImpreciseNumber, Count: 1},
+	}, first.Merge(second).Diagnostics())
+}
+
+func TestIsFloat64(t *testing.T) {
+	for _, tc := range []struct {
+		value    any
+		expected bool
+	}{
+		{json.Number("0"), true},
+		{json.Number("-0.0"), true},
+		{json.Number("0.1"), true},
+		{json.Number("123456789012345"), true},
+		{json.Number("9007199254740992"), true},
+		{json.Number("9007199254740993"), false},
+		{json.Number("0.30000000000000004"), true},
+		{json.Number("0.300000000000000001"), false},
+		{json.Number("1.7976931348623157e308"), true},
+		{json.Number("1.8e308"), false},
+		{json.Number("2.2250738585072014e-308"), true},
+		{json.Number("1e-400"), false},
+		{int64(math.MaxInt64), false},
+		{uint64(1 << 60), true},
+		{1.5, true},
+		{"x", true},
+	} {
+		assert.Equal(t, tc.expected, isFloat64(tc.value), "%v", tc.value)
+	}
+}
@@ -0,0 +1,131 @@
+package jtdinfer
+
+// MetadataExamples is the metadata key where `IntoSchema` writes the sampled
+// examples for a value.
+const MetadataExamples = "examples"
+
+// ExampleOptions configures sampling of example values for each leaf in the
+// schema, i.e. booleans, numbers, strings, timestamps and enums.
+type ExampleOptions struct {
+	// Size is the maximum number of examples to keep for each leaf. Sampling
+	// is disabled when zero.
+	Size int
+	// Seed is the seed for the random numbers used when sampling. The same
+	// seed and input will always give the same examples.
+	Seed uint64
+	// Redact is called with the path and value for each value before it's
+	// sampled and the returned value is kept instead. It can be used to mask
+	// sensitive values.
+	Redact func(path []string, value any) any
+}
+
+// Examples is a uniform random sample of the values seen for a leaf, kept with
+// reservoir sampling so it never holds more than the configured size.
+type Examples struct {
+	Values []any
+	Seen   int
+
+	rng uint64
+}
+
+// newExamples creates a new reservoir seeded with the seed.
+func newExamples(seed uint64) *Examples {
+	return &Examples{rng: seed}
+}
+
+// add returns new examples with the value sampled, see `update`. The receiver
+// isn't changed since it may be kept by an earlier inferrer.
+func (e *Examples) add(value any, size int) *Examples {
+	added := e.clone()
+	added.update(value, size)
+
+	return added
+}
+
+// update samples the value in place where the nth value seen replaces a random
+// kept value with the probability size/n.
+func (e *Examples) update(value any, size int) {
+	e.Seen++
+
+	if len(e.Values) < size {
+		e.Values = append(e.Values, value)
+		return
+	}
+
+	if j := e.next() % uint64(e.Seen); j < uint64(size) {
+		e.Values[j] = value
+	}
+}
+
+// next returns the next random number using splitmix64.
+func (e *Examples) next() uint64 {
+	e.rng += 0x9e3779b97f4a7c15
+
+	z := e.rng
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+
+	return z ^ (z >> 31)
+}
+
+// sampleExamples adds the value to the examples of the inferred leaf, keeping
+// the examples from the previous schema if it was a leaf.
+func (i *InferredSchema) sampleExamples(previous *InferredSchema, value any, hints Hints) {
+	if hints.Examples.Size <= 0 || value == nil || !i.isLeaf() {
+		return
+	}
+
+	if hints.Examples.Redact != nil {
+		value = hints.Examples.Redact(hints.path, value)
+	}
+
+	switch {
+	case previous.Examples == nil:
+		i.Examples = newExamples(hints.Examples.Seed)
+		i.Examples.update(value, hints.Examples.Size)
+	case previous.owned(hints):
+		// Examples owned by the current inference are updated in place.
+		i.Examples = previous.Examples
+		i.Examples.update(value, hints.Examples.Size)
+	default:
+		i.Examples = previous.Examples.add(value, hints.Examples.Size)
+	}
+}
+
+// isLeaf returns true if the schema type has no children.
+func (i *InferredSchema) isLeaf() bool {
+	switch i.SchemaType {
+	case SchemaTypeBoolean,
+		SchemaTypeNumber,
+		SchemaTypeString,
+		SchemaTypeTimestmap,
+		SchemaTypeEnum,
+		SchemaTypeBytes:
+		return true
+	case SchemaTypeUnknown,
+		SchemaTypeAny,
+		SchemaTypeArray,
+		SchemaTypeProperties,
+		SchemaTypeValues,
+		SchemaTypeDiscriminator,
+		SchemaTypeNullable:
+	}
+
+	return false
+}
+
+// withExamples adds the sampled examples to the schema metadata if sampling is
+// enabled.
+func (i *InferredSchema) withExamples(schema Schema, hints Hints) Schema {
+	if hints.Examples.Size <= 0 || i.Examples == nil || len(i.Examples.Values) == 0 {
+		return schema
+	}
+
+	if schema.Metadata == nil {
+		schema.Metadata = map[string]any{}
+	}
+
+	schema.Metadata[MetadataExamples] = append([]any{}, i.Examples.Values...)
+
+	return schema
+}
@@ -0,0 +1,84 @@
+package jtdinfer
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExamples(t *testing.T) {
+	rows := []string{
+		`{"name": "Joe", "email": "joe@example.com", "age": 30, "tags": ["a"]}`,
+		`{"name": "Jane", "email": "jane@example.com", "age": null, "tags": ["b", "c"]}`,
+	}
+
+	hints := Hints{
+		Examples: ExampleOptions{
+			Size: 5,
+			Redact: func(path []string, value any) any {
+				if strings.Join(path, ".") == "email" {
+					return "<redacted>"
+				}
+
+				return value
+			},
+		},
+	}
+
+	schema := InferStrings(rows, hints).IntoSchema()
+
+	assert.Equal(t, []any{"Joe", "Jane"}, schema.Properties["name"].Metadata[MetadataExamples])
+	assert.Equal(t, []any{"<redacted>", "<redacted>"}, schema.Properties["email"].Metadata[MetadataExamples])
+	assert.Len(t, schema.Properties["age"].Metadata[MetadataExamples], 1)
+	assert.True(t, schema.Properties["age"].Nullable)
+	assert.Equal(t, []any{"a", "b", "c"}, schema.Properties["tags"].Elements.Metadata[MetadataExamples])
+	assert.Nil(t, schema.Properties["tags"].Metadata)
+
+	withoutExamples := InferStrings(rows, hints).Inference.IntoSchema(WithoutHints())
+	assert.Nil(t, withoutExamples.Properties["name"].Metadata)
+}
+
+func TestExamplesReservoir(t *testing.T) {
+	rows := make([]string, 1000)
+	for i := range rows {
+		rows[i] = strconv.Itoa(i)
+	}
+
+	infer := func(seed uint64) []any {
+		inferrer := InferStrings(rows, Hints{Examples: ExampleOptions{Size: 10, Seed: seed}})
+		require.NotNil(t, inferrer.Inference.Examples)
+		assert.Equal(t, 1000, inferrer.Inference.Examples.Seen)
+
+		examples, ok := inferrer.IntoSchema().Metadata[MetadataExamples].([]any)
+		require.True(t, ok)
+
+		return examples
+	}
+
+	first := infer(1)
+	assert.Len(t, first, 10)
+	assert.Equal(t, first, infer(1))
+	assert.NotEqual(t, first, infer(2))
+
+	// With 1000 values it's very unlikely that all of the first ten values
+	// are kept.
+	firstTen := make([]any, 10)
+	for i := range firstTen {
+		firstTen[i] = json.Number(rows[i])
+	}
+
+	assert.NotEqual(t, firstTen, first)
+}
+
+func TestExamplesKeptWhenWidened(t *testing.T) {
+	schema := InferStrings(
+		[]string{`"2023-01-01T00:00:00Z"`, `"not a timestamp"`},
+		Hints{Examples: ExampleOptions{Size: 5}},
+	).IntoSchema()
+
+	assert.Equal(t, []any{"2023-01-01T00:00:00Z", "not a timestamp"}, schema.Metadata[MetadataExamples])
+}
@@ -5,10 +5,10 @@ go 1.21.0
 require (
 	github.com/jsontypedef/json-typedef-go v0.0.0-20200503043955-4280071bd745
 	github.com/stretchr/testify v1.8.4
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
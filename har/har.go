@@ -0,0 +1,266 @@
+// Package har infers schemas per endpoint from HTTP traffic recorded in HAR
+// (HTTP Archive) files.
+package har
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	jtdinfer "github.com/bombsimon/jtd-infer-go"
+	"github.com/bombsimon/jtd-infer-go/internal/pathtemplate"
+)
+
+// Options holds the options for inferring a HAR file.
+type Options struct {
+	// Hints are used for all inferred schemas.
+	Hints jtdinfer.Hints
+}
+
+// Endpoint holds the inferred schemas for a method and URL template.
+type Endpoint struct {
+	Method string
+	// Path is the URL path where numeric and UUID segments are replaced by
+	// placeholders, e.g. `/users/{id}`.
+	Path string
+	// Query is inferred from the query parameters where each parameter is a
+	// string or, if repeated, an array of strings.
+	Query *jtdinfer.Inferrer
+	// Request is inferred from the JSON request bodies.
+	Request *jtdinfer.Inferrer
+	// Responses are inferred from the JSON response bodies per status code.
+	Responses map[int]*jtdinfer.Inferrer
+	// Entries is the number of entries for the endpoint.
+	Entries int
+	// SkippedBodies is the number of JSON bodies that couldn't be decoded,
+	// e.g. because they were truncated when recorded.
+	SkippedBodies int
+}
+
+// Result holds all inferred endpoints.
+type Result struct {
+	// Endpoints sorted by path and method.
+	Endpoints []*Endpoint
+}
+
+// file is the subset of the HAR format needed to infer schemas.
+type file struct {
+	Log struct {
+		Entries []entry `json:"entries"`
+	} `json:"log"`
+}
+
+type entry struct {
+	Request struct {
+		Method      string `json:"method"`
+		URL         string `json:"url"`
+		QueryString []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"queryString"`
+		PostData *struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+		} `json:"postData"`
+	} `json:"request"`
+	Response struct {
+		Status  int `json:"status"`
+		Content struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+			Encoding string `json:"encoding"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+// Infer reads the HAR file and infers the query parameters, request bodies and
+// response bodies per status code for each endpoint. Entries are grouped by
+// method and URL path where numeric and UUID path segments are collapsed. Only
+// bodies with a JSON mime type are inferred.
+func Infer(r io.Reader, opts Options) (*Result, error) {
+	var f file
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("invalid HAR: %w", err)
+	}
+
+	endpoints := map[string]*Endpoint{}
+
+	for i, e := range f.Log.Entries {
+		u, err := url.Parse(e.Request.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL in entry %d: %w", i, err)
+		}
+
+		method := strings.ToUpper(e.Request.Method)
+		path := pathtemplate.Normalize(u.Path)
+		key := method + " " + path
+
+		endpoint, ok := endpoints[key]
+		if !ok {
+			endpoint = &Endpoint{
+				Method:    method,
+				Path:      path,
+				Responses: map[int]*jtdinfer.Inferrer{},
+			}
+			endpoints[key] = endpoint
+		}
+
+		endpoint.Entries++
+
+		if len(e.Request.QueryString) > 0 {
+			query := map[string]any{}
+
+			for _, q := range e.Request.QueryString {
+				switch v := query[q.Name].(type) {
+				case nil:
+					query[q.Name] = q.Value
+				case string:
+					query[q.Name] = []any{v, q.Value}
+				case []any:
+					query[q.Name] = append(v, q.Value)
+				}
+			}
+
+			endpoint.Query = infer(endpoint.Query, query, opts.Hints)
+		}
+
+		if e.Request.PostData != nil {
+			value, ok, err := decodeBody(e.Request.PostData.MimeType, e.Request.PostData.Text, "")
+			if err != nil {
+				endpoint.SkippedBodies++
+			} else if ok {
+				endpoint.Request = infer(endpoint.Request, value, opts.Hints)
+			}
+		}
+
+		content := e.Response.Content
+
+		value, ok, err := decodeBody(content.MimeType, content.Text, content.Encoding)
+		if err != nil {
+			endpoint.SkippedBodies++
+		} else if ok {
+			status := e.Response.Status
+			endpoint.Responses[status] = infer(endpoint.Responses[status], value, opts.Hints)
+		}
+	}
+
+	result := &Result{Endpoints: make([]*Endpoint, 0, len(endpoints))}
+	for _, endpoint := range endpoints {
+		result.Endpoints = append(result.Endpoints, endpoint)
+	}
+
+	sort.Slice(result.Endpoints, func(i, j int) bool {
+		a, b := result.Endpoints[i], result.Endpoints[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+
+		return a.Method < b.Method
+	})
+
+	return result, nil
+}
+
+// Schemas returns all inferred schemas named after the method, path and kind,
+// e.g. `GetUsersIdQuery`, `PostUsersRequest` and `GetUsersIdResponse200`. The
+// names are unique and can be passed to code generators.
+func (r *Result) Schemas() map[string]jtdinfer.Schema {
+	schemas := map[string]jtdinfer.Schema{}
+
+	add := func(name string, inferrer *jtdinfer.Inferrer) {
+		if inferrer == nil {
+			return
+		}
+
+		unique := name
+		for i := 2; ; i++ {
+			if _, ok := schemas[unique]; !ok {
+				break
+			}
+
+			unique = name + "_" + strconv.Itoa(i)
+		}
+
+		schemas[unique] = inferrer.IntoSchema()
+	}
+
+	for _, e := range r.Endpoints {
+		base := e.Name()
+		add(base+"Query", e.Query)
+		add(base+"Request", e.Request)
+
+		statuses := make([]int, 0, len(e.Responses))
+		for status := range e.Responses {
+			statuses = append(statuses, status)
+		}
+
+		sort.Ints(statuses)
+
+		for _, status := range statuses {
+			add(base+"Response"+strconv.Itoa(status), e.Responses[status])
+		}
+	}
+
+	return schemas
+}
+
+// Name returns the endpoint as a PascalCase name, e.g. `GetUsersId` for
+// `GET /users/{id}`.
+func (e *Endpoint) Name() string {
+	return pathtemplate.Name(e.Method + " " + e.Path)
+}
+
+func infer(inferrer *jtdinfer.Inferrer, value any, hints jtdinfer.Hints) *jtdinfer.Inferrer {
+	if inferrer == nil {
+		inferrer = jtdinfer.NewInferrer(hints)
+	}
+
+	return inferrer.Infer(value)
+}
+
+// decodeBody decodes the body if the mime type is JSON. The returned boolean is
+// false if the body isn't JSON or is empty.
+func decodeBody(mimeType, text, encoding string) (any, bool, error) {
+	if text == "" || !isJSON(mimeType) {
+		return nil, false, nil
+	}
+
+	body := []byte(text)
+
+	if encoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(text)
+		if err != nil {
+			return nil, false, err
+		}
+
+		body = decoded
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, false, err
+	}
+
+	return value, true, nil
+}
+
+// isJSON checks if the mime type is `application/json` or has a `+json`
+// suffix such as `application/problem+json`.
+func isJSON(mimeType string) bool {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
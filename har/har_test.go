@@ -0,0 +1,123 @@
+package har
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	jtd "github.com/jsontypedef/json-typedef-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jtdinfer "github.com/bombsimon/jtd-infer-go"
+)
+
+func TestInfer(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte(`{"error": "not found"}`))
+
+	input := `{
+  "log": {
+    "entries": [
+      {
+        "request": {
+          "method": "GET",
+          "url": "https://api.example.com/users/1?fields=name&fields=age&page=1",
+          "queryString": [
+            {"name": "fields", "value": "name"},
+            {"name": "fields", "value": "age"},
+            {"name": "page", "value": "1"}
+          ]
+        },
+        "response": {
+          "status": 200,
+          "content": {"mimeType": "application/json; charset=utf-8", "text": "{\"id\": 1, \"name\": \"Joe\"}"}
+        }
+      },
+      {
+        "request": {"method": "get", "url": "https://api.example.com/users/2", "queryString": []},
+        "response": {
+          "status": 200,
+          "content": {"mimeType": "application/json", "text": "{\"id\": 2, \"name\": \"Jane\"}"}
+        }
+      },
+      {
+        "request": {"method": "GET", "url": "https://api.example.com/users/3"},
+        "response": {
+          "status": 404,
+          "content": {"mimeType": "application/problem+json", "text": "` + encoded + `", "encoding": "base64"}
+        }
+      },
+      {
+        "request": {
+          "method": "POST",
+          "url": "https://api.example.com/users",
+          "postData": {"mimeType": "application/json", "text": "{\"name\": \"Bob\"}"}
+        },
+        "response": {
+          "status": 201,
+          "content": {"mimeType": "application/json", "text": "{\"id\": 3, \"name\": \"Bo"}
+        }
+      },
+      {
+        "request": {"method": "GET", "url": "https://api.example.com/index.html"},
+        "response": {"status": 200, "content": {"mimeType": "text/html", "text": "<html></html>"}}
+      }
+    ]
+  }
+}`
+
+	result, err := Infer(strings.NewReader(input), Options{})
+	require.NoError(t, err)
+	require.Len(t, result.Endpoints, 3)
+
+	assert.Equal(t, "/index.html", result.Endpoints[0].Path)
+	assert.Empty(t, result.Endpoints[0].Responses)
+
+	users := result.Endpoints[1]
+	assert.Equal(t, "POST", users.Method)
+	assert.Equal(t, 1, users.SkippedBodies)
+
+	user := result.Endpoints[2]
+	assert.Equal(t, "GET", user.Method)
+	assert.Equal(t, "/users/{id}", user.Path)
+	assert.Equal(t, 3, user.Entries)
+
+	schemas := result.Schemas()
+
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+
+	assert.ElementsMatch(t, []string{
+		"PostUsersRequest",
+		"GetUsersIdQuery",
+		"GetUsersIdResponse200",
+		"GetUsersIdResponse404",
+	}, names)
+
+	assert.Equal(t, jtdinfer.Schema{
+		Properties: map[string]jtdinfer.Schema{
+			"fields": {Elements: &jtdinfer.Schema{Type: jtd.TypeString}},
+			"page":   {Type: jtd.TypeString},
+		},
+	}, schemas["GetUsersIdQuery"])
+
+	assert.Equal(t, jtdinfer.Schema{
+		Properties: map[string]jtdinfer.Schema{
+			"id":   {Type: jtd.TypeUint8},
+			"name": {Type: jtd.TypeString},
+		},
+	}, schemas["GetUsersIdResponse200"])
+
+	assert.Equal(t, jtdinfer.Schema{
+		Properties: map[string]jtdinfer.Schema{
+			"error": {Type: jtd.TypeString},
+		},
+	}, schemas["GetUsersIdResponse404"])
+}
+
+func TestInferInvalid(t *testing.T) {
+	_, err := Infer(strings.NewReader(`{"log": `), Options{})
+	require.Error(t, err)
+}
@@ -0,0 +1,355 @@
+package jtdinfer
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// maxPatternMemo is the number of keys matching a pattern to remember per
+// state. Keys beyond this are matched without being remembered so the memory
+// stays bounded for unbounded keys such as map keys.
+const maxPatternMemo = 1024
+
+// hintAutomaton is a `HintSet` compiled into an automaton. Each position in
+// each path is numbered and a state is the set of positions matched so far.
+// States are determinized when compiling for exact keys and wildcards so
+// finding the sub hints for a key is a single map lookup. Keys matching a glob
+// or a regular expression are determinized lazily and remembered per state.
+// The automaton is safe for concurrent use.
+type hintAutomaton struct {
+	paths [][]hintSegment
+	// positions maps each position to its path and segment index. The
+	// positions for a path are consecutive so the next position is the
+	// position plus one.
+	positions []hintPosition
+
+	mu     sync.Mutex
+	states map[string]*hintState
+}
+
+type hintPosition struct {
+	path    int
+	segment int
+}
+
+// hintState is a state in a `hintAutomaton`. The state is never modified
+// once compiled except for the pattern memo. A nil state has no hints.
+type hintState struct {
+	automaton *hintAutomaton
+	positions []int
+
+	// active is true if any path is matched, see `HintSet.IsActive`.
+	active bool
+	// peek is the last segment of the first path where all other segments
+	// are matched and the last segment is an exact key, see
+	// `HintSet.PeekActive`.
+	peek    string
+	hasPeek bool
+
+	exact    map[string]*hintState
+	other    *hintState
+	patterns []hintSegment
+	// memo holds the states for keys matching any pattern. It's guarded by
+	// the automaton mutex.
+	memo map[string]*hintState
+}
+
+// compileHintSet compiles the hint set into an automaton and returns the
+// initial state. Invalid segments are compiled to never match any key.
+func compileHintSet(h HintSet) *hintState {
+	if len(h.Values) == 0 {
+		return nil
+	}
+
+	a := &hintAutomaton{
+		paths:  make([][]hintSegment, len(h.Values)),
+		states: map[string]*hintState{},
+	}
+
+	initial := []int{}
+
+	for idx, values := range h.Values {
+		a.paths[idx] = make([]hintSegment, len(values))
+
+		for i, value := range values {
+			a.paths[idx][i], _ = parseHintSegment(value)
+		}
+
+		first := len(a.positions)
+		for i := 0; i <= len(values); i++ {
+			a.positions = append(a.positions, hintPosition{path: idx, segment: i})
+		}
+
+		initial = a.closure(initial, first)
+	}
+
+	return a.state(initial)
+}
+
+// segment returns the segment at the position or false if the path is matched.
+func (a *hintAutomaton) segment(position int) (hintSegment, bool) {
+	p := a.positions[position]
+	if p.segment == len(a.paths[p.path]) {
+		return hintSegment{}, false
+	}
+
+	return a.paths[p.path][p.segment], true
+}
+
+// isLast returns true if the position is the last segment in its path.
+func (a *hintAutomaton) isLast(position int) bool {
+	p := a.positions[position]
+	return p.segment == len(a.paths[p.path])-1
+}
+
+// closure adds the position and all positions after recursive wildcards since
+// they can match zero keys.
+func (a *hintAutomaton) closure(positions []int, position int) []int {
+	positions = append(positions, position)
+
+	for {
+		segment, ok := a.segment(position)
+		if !ok || segment.kind != segmentRecursive {
+			return positions
+		}
+
+		position++
+		positions = append(positions, position)
+	}
+}
+
+// step returns the positions after matching a key where match tells if a
+// segment that isn't a wildcard matches the key.
+func (a *hintAutomaton) step(positions []int, match func(hintSegment) bool) []int {
+	next := []int{}
+
+	for _, position := range positions {
+		segment, ok := a.segment(position)
+
+		switch {
+		case !ok:
+		case segment.kind == segmentRecursive:
+			next = a.closure(next, position)
+		case segment.kind == segmentWildcard || match(segment):
+			next = a.closure(next, position+1)
+		}
+	}
+
+	return next
+}
+
+// state returns the state for the positions, creating it and all states
+// reachable by exact keys and other keys if needed. The caller must hold the
+// mutex unless compiling.
+func (a *hintAutomaton) state(positions []int) *hintState {
+	if len(positions) == 0 {
+		return nil
+	}
+
+	sort.Ints(positions)
+
+	unique := positions[:1]
+	for _, position := range positions[1:] {
+		if position != unique[len(unique)-1] {
+			unique = append(unique, position)
+		}
+	}
+
+	key := make([]byte, 0, len(unique)*4)
+	for _, position := range unique {
+		key = strconv.AppendInt(key, int64(position), 10)
+		key = append(key, ',')
+	}
+
+	if s, ok := a.states[string(key)]; ok {
+		return s
+	}
+
+	s := &hintState{
+		automaton: a,
+		positions: unique,
+	}
+
+	// The state is added before finding the next states since recursive
+	// wildcards can lead back to the same state.
+	a.states[string(key)] = s
+
+	exactKeys := []string{}
+
+	for _, position := range unique {
+		segment, ok := a.segment(position)
+
+		switch {
+		case !ok:
+			s.active = true
+		case segment.isPattern():
+			s.patterns = append(s.patterns, segment)
+		case segment.kind == segmentExact:
+			exactKeys = append(exactKeys, segment.value)
+		}
+
+		// The positions are sorted so the first path found is the first
+		// path in the hint set.
+		if !s.hasPeek && ok && segment.kind == segmentExact && a.isLast(position) {
+			s.peek, s.hasPeek = segment.value, true
+		}
+	}
+
+	for _, k := range exactKeys {
+		if _, ok := s.exact[k]; ok {
+			continue
+		}
+
+		if s.exact == nil {
+			s.exact = map[string]*hintState{}
+		}
+
+		s.exact[k] = a.state(a.step(unique, func(segment hintSegment) bool {
+			return segment.kind == segmentExact && segment.value == k
+		}))
+	}
+
+	s.other = a.state(a.step(unique, func(hintSegment) bool {
+		return false
+	}))
+
+	return s
+}
+
+// child returns the state for the sub hints for the key.
+func (s *hintState) child(key string) *hintState {
+	if s == nil {
+		return nil
+	}
+
+	for _, segment := range s.patterns {
+		if segment.matches(key) {
+			return s.patternChild(key)
+		}
+	}
+
+	if child, ok := s.exact[key]; ok {
+		return child
+	}
+
+	return s.other
+}
+
+// patternChild returns the state for a key matching any pattern.
+func (s *hintState) patternChild(key string) *hintState {
+	a := s.automaton
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if child, ok := s.memo[key]; ok {
+		return child
+	}
+
+	child := a.state(a.step(s.positions, func(segment hintSegment) bool {
+		return segment.matches(key)
+	}))
+
+	if len(s.memo) < maxPatternMemo {
+		if s.memo == nil {
+			s.memo = map[string]*hintState{}
+		}
+
+		s.memo[key] = child
+	}
+
+	return child
+}
+
+// needsKey returns true if the key is needed to find the child, i.e. if any
+// segment isn't a wildcard.
+func (s *hintState) needsKey() bool {
+	return s != nil && (len(s.exact) > 0 || len(s.patterns) > 0)
+}
+
+func (s *hintState) isActive() bool {
+	return s != nil && s.active
+}
+
+func (s *hintState) peekActive() (string, bool) {
+	if s == nil {
+		return "", false
+	}
+
+	return s.peek, s.hasPeek
+}
+
+// compiledHints holds the compiled hint sets together with a copy of the hint
+// sets they were compiled from.
+type compiledHints struct {
+	enums         *hintState
+	values        *hintState
+	discriminator *hintState
+
+	from [3][][]string
+}
+
+// compileHints compiles all hint sets in the hints. The paths are copied so
+// paths changed in place after compiling are detected by `matches`.
+func compileHints(h Hints) *compiledHints {
+	c := &compiledHints{
+		from: [3][][]string{
+			copyPaths(h.Enums.Values),
+			copyPaths(h.Values.Values),
+			copyPaths(h.Discriminator.Values),
+		},
+	}
+
+	c.enums = compileHintSet(h.Enums)
+	c.values = compileHintSet(h.Values)
+	c.discriminator = compileHintSet(h.Discriminator)
+
+	return c
+}
+
+// matches returns true if the hint sets holds the same paths as when compiled.
+// Comparing the segments is much cheaper than compiling the hints again for
+// every inferred value.
+func (c *compiledHints) matches(h Hints) bool {
+	if c == nil {
+		return false
+	}
+
+	for idx, values := range [3][][]string{h.Enums.Values, h.Values.Values, h.Discriminator.Values} {
+		if len(c.from[idx]) != len(values) {
+			return false
+		}
+
+		for i := range values {
+			if !slices.Equal(c.from[idx][i], values[i]) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func copyPaths(paths [][]string) [][]string {
+	copied := make([][]string, len(paths))
+	for i, path := range paths {
+		copied[i] = slices.Clone(path)
+	}
+
+	return copied
+}
+
+// withCompiled returns the hints where sub hints are found with the compiled
+// automatons instead of the hint sets. The hint sets are cleared since they're
+// no longer updated.
+func (h Hints) withCompiled(c *compiledHints) Hints {
+	h.compiled = true
+	h.enumsState = c.enums
+	h.valuesState = c.values
+	h.discriminatorState = c.discriminator
+	h.Enums, h.Values, h.Discriminator = HintSet{}, HintSet{}, HintSet{}
+
+	return h
+}
@@ -0,0 +1,341 @@
+package jtdinfer
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"sync"
+	"testing"
+
+	jtd "github.com/jsontypedef/json-typedef-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertEquivalent walks the keys with both the hint set and the compiled
+// automaton and asserts that they agree at every step.
+func assertEquivalent(t *testing.T, hs HintSet, keys []string) {
+	t.Helper()
+
+	state := compileHintSet(hs)
+
+	for depth := 0; ; depth++ {
+		assert.Equal(t, hs.IsActive(), state.isActive(), "active at %v", keys[:depth])
+
+		wantPeek, wantOK := hs.PeekActive()
+		gotPeek, gotOK := state.peekActive()
+		assert.Equal(t, wantOK, gotOK, "peek at %v", keys[:depth])
+		assert.Equal(t, wantPeek, gotPeek, "peek at %v", keys[:depth])
+
+		if depth == len(keys) {
+			return
+		}
+
+		hs = hs.SubHints(keys[depth])
+		state = state.child(keys[depth])
+	}
+}
+
+func TestHintAutomaton(t *testing.T) {
+	cases := []struct {
+		description string
+		hints       [][]string
+		keys        []string
+	}{
+		{
+			description: "exact",
+			hints:       [][]string{{"a", "b", "c"}},
+			keys:        []string{"a", "b", "c"},
+		},
+		{
+			description: "wildcard",
+			hints:       [][]string{{"a", "b", "c"}, {"d", "-", "e"}},
+			keys:        []string{"d", "x", "e"},
+		},
+		{
+			description: "wildcard and exact at same level",
+			hints:       [][]string{{"-", "b"}, {"a", "c"}},
+			keys:        []string{"a", "b"},
+		},
+		{
+			description: "first hint is peeked",
+			hints:       [][]string{{"a", "kind"}, {"-", "type"}},
+			keys:        []string{"a"},
+		},
+		{
+			description: "wildcard peeked before exact",
+			hints:       [][]string{{"-", "type"}, {"a", "kind"}},
+			keys:        []string{"a"},
+		},
+		{
+			description: "wildcard as value",
+			hints:       [][]string{{"a", "-"}},
+			keys:        []string{"a", "x"},
+		},
+		{
+			description: "root",
+			hints:       [][]string{{}},
+			keys:        []string{"a"},
+		},
+		{
+			description: "no match",
+			hints:       [][]string{{"a", "b"}},
+			keys:        []string{"x", "b"},
+		},
+		{
+			description: "recursive wildcard",
+			hints:       [][]string{{"**", "type"}},
+			keys:        []string{"a", "b", "type"},
+		},
+		{
+			description: "recursive wildcard matching zero keys",
+			hints:       [][]string{{"a", "**", "b"}},
+			keys:        []string{"a", "b", "b"},
+		},
+		{
+			description: "trailing recursive wildcard",
+			hints:       [][]string{{"a", "**"}},
+			keys:        []string{"a", "b", "c"},
+		},
+		{
+			description: "consecutive recursive wildcards",
+			hints:       [][]string{{"**", "**", "a"}},
+			keys:        []string{"b", "a", "a"},
+		},
+		{
+			description: "glob",
+			hints:       [][]string{{"*_id"}, {"a?", "[xy]"}},
+			keys:        []string{"ab", "y"},
+		},
+		{
+			description: "escaped glob",
+			hints:       [][]string{{`\*`}},
+			keys:        []string{"*"},
+		},
+		{
+			description: "regexp",
+			hints:       [][]string{{"/^.*_type$/", "a"}},
+			keys:        []string{"event_type", "a"},
+		},
+		{
+			description: "pattern and exact matching same key",
+			hints:       [][]string{{"a*", "b"}, {"ab", "c"}},
+			keys:        []string{"ab", "c"},
+		},
+		{
+			description: "exact peeked before glob",
+			hints:       [][]string{{"a", "kind"}, {"a*", "type"}},
+			keys:        []string{"a"},
+		},
+		{
+			description: "glob peeked before exact",
+			hints:       [][]string{{"a*", "type"}, {"a", "kind"}},
+			keys:        []string{"a"},
+		},
+		{
+			description: "regexp peeked before glob",
+			hints:       [][]string{{"/^a/", "kind"}, {"a*", "type"}},
+			keys:        []string{"a"},
+		},
+		{
+			description: "wildcard peeked before regexp",
+			hints:       [][]string{{"-", "kind"}, {"/^a/", "type"}},
+			keys:        []string{"a"},
+		},
+		{
+			description: "recursive wildcard peeked before exact",
+			hints:       [][]string{{"**", "kind"}, {"a", "type"}},
+			keys:        []string{"a"},
+		},
+		{
+			description: "exact peeked before recursive wildcard",
+			hints:       [][]string{{"a", "type"}, {"**", "kind"}},
+			keys:        []string{"a"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			assertEquivalent(t, HintSet{Values: tc.hints}, tc.keys)
+		})
+	}
+}
+
+func TestHintAutomatonRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	segments := []string{"a", "b", "c", "a*", "/b$/", Wildcard, RecursiveWildcard}
+
+	randomPath := func(maxLen int) []string {
+		path := make([]string, rng.Intn(maxLen+1))
+		for i := range path {
+			path[i] = segments[rng.Intn(len(segments))]
+		}
+
+		return path
+	}
+
+	for i := 0; i < 1000; i++ {
+		hs := NewHintSet()
+		for n := rng.Intn(6); n >= 0; n-- {
+			hs = hs.Add(randomPath(4))
+		}
+
+		keys := make([]string, rng.Intn(6))
+		for k := range keys {
+			keys[k] = []string{"a", "b", "c", "ab", "ba", "x"}[rng.Intn(6)]
+		}
+
+		assertEquivalent(t, hs, keys)
+	}
+}
+
+func TestInferrerRecompilesChangedHints(t *testing.T) {
+	inferrer := NewInferrer(WithoutHints()).Infer(map[string]any{"status": "ok"})
+	assert.Equal(t, Schema{Type: "string"}, inferrer.IntoSchema().Properties["status"])
+
+	inferrer = NewInferrer(WithoutHints())
+	inferrer.Hints.Enums = NewHintSet().Add([]string{"status"})
+	inferrer = inferrer.Infer(map[string]any{"status": "ok"})
+
+	require.Contains(t, inferrer.IntoSchema().Properties, "status")
+	assert.Equal(t, []string{"ok"}, inferrer.IntoSchema().Properties["status"].Enum)
+}
+
+func TestInferrerRecompilesHintsChangedInPlace(t *testing.T) {
+	hints := WithoutHints()
+	hints.Enums = NewHintSet().Add([]string{"user", "status"})
+
+	inferrer := NewInferrer(hints)
+	inferrer.Hints.Enums.Values[0][1] = "state"
+	inferrer = inferrer.Infer(map[string]any{"user": map[string]any{"state": "ok"}})
+
+	user := inferrer.IntoSchema().Properties["user"]
+	require.Contains(t, user.Properties, "state")
+	assert.Equal(t, []string{"ok"}, user.Properties["state"].Enum)
+}
+
+func TestInferPatternHints(t *testing.T) {
+	rows := []string{
+		`{"status_code": "ok", "event": {"type": "a", "child": {"type": "b", "x": 1}}}`,
+		`{"status_code": "failed", "event": {"type": "c"}, "meta": {"labels": {"x": "y"}}}`,
+	}
+
+	hints := Hints{
+		Enums:         NewHintSet().Add([]string{"/^status_/"}),
+		Values:        NewHintSet().Add([]string{"**", "labels"}),
+		Discriminator: NewHintSet().Add([]string{"**", "event", "type"}).Add([]string{"**", "child", "type"}),
+	}
+
+	schema := InferStrings(rows, hints).IntoSchema()
+
+	assert.ElementsMatch(t, []string{"ok", "failed"}, schema.Properties["status_code"].Enum)
+	assert.NotNil(t, schema.OptionalProperties["meta"].Properties["labels"].Values)
+
+	event := schema.Properties["event"]
+	assert.Equal(t, "type", event.Discriminator)
+	require.Contains(t, event.Mapping, "a")
+	assert.Equal(t, "type", event.Mapping["a"].Properties["child"].Discriminator)
+}
+
+func TestHintAutomatonConcurrent(t *testing.T) {
+	state := compileHintSet(NewHintSet().Add([]string{"**", "*_id"}))
+
+	var wg sync.WaitGroup
+
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 2*maxPatternMemo; i++ {
+				child := state.child("a").child(strconv.Itoa(i%100) + "_id")
+				assert.True(t, child.isActive())
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestInvalidHints(t *testing.T) {
+	for _, segment := range []string{"[a", "/(/"} {
+		hs := NewHintSet().Add([]string{"a", segment})
+		require.Error(t, hs.Validate())
+
+		assert.False(t, hs.SubHints("a").SubHints("a").IsActive())
+
+		assertEquivalent(t, hs, []string{"a", "a"})
+		assertEquivalent(t, hs, []string{"a", segment})
+
+		// Invalid segments never match but the other paths are still used.
+		hints := Hints{Enums: hs.Add([]string{"b"})}
+
+		value := map[string]any{"a": map[string]any{"a": "x"}, "b": "y"}
+
+		inferrer, err := NewInferrer(hints).InferContext(context.Background(), value)
+		require.NoError(t, err)
+
+		schema := inferrer.IntoSchema()
+		assert.Equal(t, Schema{Type: jtd.TypeString}, schema.Properties["a"].Properties["a"])
+		assert.Equal(t, []string{"y"}, schema.Properties["b"].Enum)
+	}
+}
+
+func TestQuoteHintKey(t *testing.T) {
+	for _, key := range []string{"a", "-", "**", "*", "*_id", "/users/", "/", "a[b", `a\b`, "?", "-a", "/a"} {
+		segment := QuoteHintKey(key)
+
+		hs := NewHintSet().Add([]string{segment})
+		require.NoError(t, hs.Validate(), key)
+
+		assert.True(t, hs.SubHints(key).IsActive(), key)
+		assert.True(t, compileHintSet(hs).child(key).isActive(), key)
+
+		for _, other := range []string{"", "x", "a_id", "users", "ab", "-b", "/b/"} {
+			if other != key {
+				assert.False(t, hs.SubHints(other).IsActive(), "%s matches %s", segment, other)
+				assert.False(t, compileHintSet(hs).child(other).isActive(), "%s matches %s", segment, other)
+			}
+		}
+	}
+
+	assert.Equal(t, "status", QuoteHintKey("status"))
+	assert.Equal(t, `\/users/`, QuoteHintKey("/users/"))
+}
+
+func BenchmarkSubHints(b *testing.B) {
+	hs := NewHintSet()
+	for i := 0; i < 500; i++ {
+		hs = hs.Add([]string{"items", Wildcard, "field" + strconv.Itoa(i)})
+	}
+
+	keys := make([]string, 1000)
+	for i := range keys {
+		keys[i] = "field" + strconv.Itoa(i)
+	}
+
+	b.Run("hint set", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for n := 0; n < b.N; n++ {
+			element := hs.SubHints("items").SubHints("0")
+			for _, key := range keys {
+				_ = element.SubHints(key).IsActive()
+			}
+		}
+	})
+
+	b.Run("automaton", func(b *testing.B) {
+		b.ReportAllocs()
+
+		state := compileHintSet(hs)
+
+		for n := 0; n < b.N; n++ {
+			element := state.child("items").child("0")
+			for _, key := range keys {
+				_ = element.child(key).isActive()
+			}
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package jtdinfer
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// RecursiveWildcard represents the segment that matches zero or more keys for
+// hints.
+const RecursiveWildcard = "**"
+
+type segmentKind uint8
+
+const (
+	segmentExact segmentKind = iota
+	segmentWildcard
+	segmentRecursive
+	segmentGlob
+	segmentRegexp
+	segmentInvalid
+)
+
+// hintSegment is a parsed segment in a hint path. A segment is either:
+//   - `-` which matches any key
+//   - `**` which matches zero or more keys
+//   - a regular expression enclosed in slashes such as `/^.*_type$/`
+//   - a glob as matched by `path.Match` such as `*_id` if it contains any of
+//     `*?[\`, use `\` to escape
+//   - the exact key
+//
+// An invalid glob or regular expression never matches any key.
+type hintSegment struct {
+	kind   segmentKind
+	value  string
+	regexp *regexp.Regexp
+}
+
+// QuoteHintKey returns a segment that only matches the exact key. Keys that
+// would be parsed as a wildcard, a glob or a regular expression are escaped as
+// a glob matching only the key, e.g. `/users/` is returned as `\/users/`.
+func QuoteHintKey(key string) string {
+	isRegexp := len(key) >= 2 && key[0] == '/' && key[len(key)-1] == '/'
+
+	if key != Wildcard && key != RecursiveWildcard && !isRegexp && !strings.ContainsAny(key, `*?[\`) {
+		return key
+	}
+
+	var sb strings.Builder
+
+	for i, r := range key {
+		if strings.ContainsRune(`*?[\`, r) || (i == 0 && (r == '/' || r == '-')) {
+			sb.WriteByte('\\')
+		}
+
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
+
+func parseHintSegment(segment string) (hintSegment, error) {
+	s := hintSegment{value: segment}
+
+	switch {
+	case segment == Wildcard:
+		s.kind = segmentWildcard
+	case segment == RecursiveWildcard:
+		s.kind = segmentRecursive
+	case len(segment) >= 2 && segment[0] == '/' && segment[len(segment)-1] == '/':
+		re, err := regexp.Compile(segment[1 : len(segment)-1])
+		if err != nil {
+			s.kind = segmentInvalid
+			return s, fmt.Errorf("jtdinfer: invalid hint segment %q: %w", segment, err)
+		}
+
+		s.kind = segmentRegexp
+		s.regexp = re
+	case strings.ContainsAny(segment, `*?[\`):
+		if _, err := path.Match(segment, ""); err != nil {
+			s.kind = segmentInvalid
+			return s, fmt.Errorf("jtdinfer: invalid hint segment %q: %w", segment, err)
+		}
+
+		s.kind = segmentGlob
+	default:
+		s.kind = segmentExact
+	}
+
+	return s, nil
+}
+
+// isPattern returns true if the segment must be evaluated to match a key.
+func (s hintSegment) isPattern() bool {
+	return s.kind == segmentGlob || s.kind == segmentRegexp
+}
+
+// matches returns true if the segment matches the key. A recursive wildcard
+// matches any key.
+func (s hintSegment) matches(key string) bool {
+	switch s.kind {
+	case segmentWildcard, segmentRecursive:
+		return true
+	case segmentGlob:
+		ok, _ := path.Match(s.value, key)
+		return ok
+	case segmentRegexp:
+		return s.regexp.MatchString(key)
+	case segmentInvalid:
+		return false
+	default:
+		return s.value == key
+	}
+}
+
+// matchSegment returns true if the segment matches the key. Invalid segments
+// never match.
+func matchSegment(segment, key string) bool {
+	switch {
+	case segment == Wildcard || segment == RecursiveWildcard:
+		return true
+	case len(segment) >= 2 && segment[0] == '/' && segment[len(segment)-1] == '/':
+		// The expression is compiled for every key here, inference uses the
+		// compiled hint sets which holds the parsed segments.
+		s, _ := parseHintSegment(segment)
+
+		return s.matches(key)
+	case strings.ContainsAny(segment, `*?[\`):
+		ok, _ := path.Match(segment, key)
+		return ok
+	default:
+		return segment == key
+	}
+}
@@ -1,15 +1,45 @@
 package jtdinfer
 
+import (
+	"strconv"
+	"unicode/utf8"
+)
+
 // Wildcard represents the character that matches any value for hints.
 const Wildcard = "-"
 
-// Hints contains the default number type to use and all the hints for enums,
-// values and discriminators.
+// Hints contains the default number type to use, the policy for selecting
+// number types, how to represent big integers and numeric strings, the limits
+// to enforce, how to sample examples, if the presence of properties should be
+// added to the schema, if diagnostics should be recorded and all the hints for
+// enums, values and discriminators.
 type Hints struct {
 	DefaultNumType NumType
-	Enums          HintSet
-	Values         HintSet
-	Discriminator  HintSet
+	NumberPolicy   NumberPolicy
+	BigInt         BigIntPolicy
+	NumericStrings NumericStringPolicy
+	Limits         Limits
+	Examples       ExampleOptions
+	// Presence adds the fraction of the seen objects where each property was
+	// present to the metadata of the property, see `MetadataPresence`.
+	Presence bool
+	// Diagnose records issues in the input that makes it ambiguous under JTD,
+	// see `Inferrer.Diagnostics`.
+	Diagnose      bool
+	Enums         HintSet
+	Values        HintSet
+	Discriminator HintSet
+
+	depth int
+	path  []string
+	state *inferState
+
+	// The compiled hint sets are used instead of the hint sets if compiled
+	// is set, see `withCompiled`.
+	compiled           bool
+	enumsState         *hintState
+	valuesState        *hintState
+	discriminatorState *hintState
 }
 
 // WithoutHints is a shorthand to return empty hints.
@@ -19,31 +49,111 @@ func WithoutHints() Hints {
 
 // SubHints will return the sub hints for all hint sets for the passed key.
 func (h Hints) SubHints(key string) Hints {
-	return Hints{
-		DefaultNumType: h.DefaultNumType,
-		Enums:          h.Enums.SubHints(key),
-		Values:         h.Values.SubHints(key),
-		Discriminator:  h.Discriminator.SubHints(key),
+	subHints := h
+	subHints.depth++
+
+	// The path is only needed to redact examples and for diagnostics so don't
+	// allocate it unless it's used.
+	if h.needsPath() {
+		subHints.path = append(h.path[:len(h.path):len(h.path)], key)
+	}
+
+	if h.diagnosing() && !utf8.ValidString(key) {
+		subHints.diagnose(DiagnosticInvalidUTF8)
+	}
+
+	if h.compiled {
+		subHints.enumsState = h.enumsState.child(key)
+		subHints.valuesState = h.valuesState.child(key)
+		subHints.discriminatorState = h.discriminatorState.child(key)
+
+		return subHints
+	}
+
+	if len(h.Enums.Values) > 0 {
+		subHints.Enums = h.Enums.SubHints(key)
+	}
+
+	if len(h.Values.Values) > 0 {
+		subHints.Values = h.Values.SubHints(key)
+	}
+
+	if len(h.Discriminator.Values) > 0 {
+		subHints.Discriminator = h.Discriminator.SubHints(key)
+	}
+
+	return subHints
+}
+
+// subHintsIndex works like `SubHints` for an array index but only formats the
+// index if any hint or the path needs it.
+func (h Hints) subHintsIndex(idx int) Hints {
+	if h.needsKey() {
+		return h.SubHints(strconv.Itoa(idx))
+	}
+
+	subHints := h
+	subHints.depth++
+	subHints.enumsState = h.enumsState.child("")
+	subHints.valuesState = h.valuesState.child("")
+	subHints.discriminatorState = h.discriminatorState.child("")
+
+	return subHints
+}
+
+// needsPath returns true if the path to the value is needed.
+func (h Hints) needsPath() bool {
+	return (h.Examples.Size > 0 && h.Examples.Redact != nil) || h.diagnosing()
+}
+
+// needsKey returns true if the key is needed to find the sub hints.
+func (h Hints) needsKey() bool {
+	if h.needsPath() {
+		return true
 	}
+
+	if h.compiled {
+		return h.enumsState.needsKey() || h.valuesState.needsKey() || h.discriminatorState.needsKey()
+	}
+
+	return len(h.Enums.Values) > 0 || len(h.Values.Values) > 0 || len(h.Discriminator.Values) > 0
 }
 
 // IsEnumActive checks if the enum hint set is active.
 func (h Hints) IsEnumActive() bool {
+	if h.compiled {
+		return h.enumsState.isActive()
+	}
+
 	return h.Enums.IsActive()
 }
 
 // IsValuesActive checks if the values hint set is active.
 func (h Hints) IsValuesActive() bool {
+	if h.compiled {
+		return h.valuesState.isActive()
+	}
+
 	return h.Values.IsActive()
 }
 
 // PeekActiveDiscriminator will peek the currently active discriminator, if any.
 // The returned boolean tells if there is an active discriminator.
 func (h Hints) PeekActiveDiscriminator() (string, bool) {
+	if h.compiled {
+		return h.discriminatorState.peekActive()
+	}
+
 	return h.Discriminator.PeekActive()
 }
 
-// HintSet represents a list of paths (lists) to match for hints.
+// HintSet represents a list of paths (lists) to match for hints. Each segment
+// in a path is matched against a key. Besides exact keys a segment can be
+// `Wildcard` to match any key, `RecursiveWildcard` to match zero or more keys,
+// a glob such as `*_id` as matched by `path.Match` or a regular expression
+// enclosed in slashes such as `/^.*_type$/`. Use `QuoteHintKey` to match a key
+// exactly. If several paths match the same value the path added first takes
+// precedence, so add specific paths before general ones.
 type HintSet struct {
 	Values [][]string
 }
@@ -61,18 +171,35 @@ func (h HintSet) Add(v []string) HintSet {
 	return h
 }
 
+// Validate returns an error if any segment is an invalid glob or regular
+// expression. Invalid segments never match any key so use this to find paths
+// that won't be used.
+func (h HintSet) Validate() error {
+	for _, values := range h.Values {
+		for _, segment := range values {
+			if _, err := parseHintSegment(segment); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 // SubHints will filter all the current sets and keep those who's first element
-// matches the passed key or wildcard.
+// matches the passed key. A path starting with a recursive wildcard is kept as
+// is since it can match more keys, and is also matched without the wildcard
+// since it can match zero keys.
 func (h HintSet) SubHints(key string) HintSet {
 	filteredValues := [][]string{}
 
 	for _, values := range h.Values {
-		if len(values) == 0 {
-			continue
+		for len(values) > 0 && values[0] == RecursiveWildcard {
+			filteredValues = append(filteredValues, values)
+			values = values[1:]
 		}
 
-		first := values[0]
-		if first == Wildcard || first == key {
+		if len(values) > 0 && matchSegment(values[0], key) {
 			filteredValues = append(filteredValues, values[1:])
 		}
 	}
@@ -82,10 +209,11 @@ func (h HintSet) SubHints(key string) HintSet {
 	}
 }
 
-// IsActive returns true if any set in the hint set his active.
+// IsActive returns true if any set in the hint set his active, i.e. if all
+// segments are matched or only recursive wildcards are left.
 func (h HintSet) IsActive() bool {
 	for _, valueList := range h.Values {
-		if len(valueList) == 0 {
+		if onlyRecursive(valueList) {
 			return true
 		}
 	}
@@ -93,16 +221,33 @@ func (h HintSet) IsActive() bool {
 	return false
 }
 
-// PeekActive returns the currently active value if any. The returned boolean
-// tells if a value was found.
+// PeekActive returns the currently active value if any, i.e. the last segment
+// of the first path where all other segments are matched. Only exact keys are
+// returned, paths ending with a wildcard, a glob or a regular expression are
+// skipped. The returned boolean tells if a value was found.
 func (h HintSet) PeekActive() (string, bool) {
 	for _, values := range h.Values {
-		if len(values) != 1 {
+		if len(values) == 0 || !onlyRecursive(values[:len(values)-1]) {
+			continue
+		}
+
+		if s, _ := parseHintSegment(values[len(values)-1]); s.kind != segmentExact {
 			continue
 		}
 
-		return values[0], true
+		return values[len(values)-1], true
 	}
 
 	return "", false
 }
+
+// onlyRecursive returns true if all segments are recursive wildcards.
+func onlyRecursive(values []string) bool {
+	for _, value := range values {
+		if value != RecursiveWildcard {
+			return false
+		}
+	}
+
+	return true
+}
@@ -52,3 +52,92 @@ func TestHintSetWildcard(t *testing.T) {
 	assert.False(t, hs.SubHints("a").SubHints("x").SubHints("c").IsActive())
 	assert.True(t, hs.SubHints("d").SubHints("x").SubHints("e").IsActive())
 }
+
+func TestHintSetPatterns(t *testing.T) {
+	cases := []struct {
+		description string
+		path        []string
+		keys        []string
+		active      bool
+	}{
+		{description: "recursive wildcard at root", path: []string{"**", "b"}, keys: []string{"b"}, active: true},
+		{description: "recursive wildcard nested", path: []string{"**", "b"}, keys: []string{"x", "y", "b"}, active: true},
+		{description: "recursive wildcard no match", path: []string{"**", "b"}, keys: []string{"b", "x"}},
+		{
+			description: "recursive wildcard in the middle",
+			path:        []string{"a", "**", "b"},
+			keys:        []string{"a", "x", "b"},
+			active:      true,
+		},
+		{description: "trailing recursive wildcard", path: []string{"a", "**"}, keys: []string{"a"}, active: true},
+		{description: "glob", path: []string{"*_id"}, keys: []string{"user_id"}, active: true},
+		{description: "glob no match", path: []string{"*_id"}, keys: []string{"user"}},
+		{description: "glob character class", path: []string{"v[0-9]"}, keys: []string{"v2"}, active: true},
+		{description: "escaped glob", path: []string{`a\*`}, keys: []string{"a*"}, active: true},
+		{description: "escaped glob no match", path: []string{`a\*`}, keys: []string{"ab"}},
+		{description: "regexp", path: []string{"/^.*_type$/"}, keys: []string{"event_type"}, active: true},
+		{description: "regexp no match", path: []string{"/^.*_type$/"}, keys: []string{"types"}},
+		{description: "unanchored regexp", path: []string{"/type/"}, keys: []string{"subtypes"}, active: true},
+		{
+			description: "recursive wildcard and glob",
+			path:        []string{"**", "*_at"},
+			keys:        []string{"a", "created_at"},
+			active:      true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			hs := NewHintSet().Add(tc.path)
+			for _, key := range tc.keys {
+				hs = hs.SubHints(key)
+			}
+
+			assert.Equal(t, tc.active, hs.IsActive())
+		})
+	}
+}
+
+func TestHintSetPrecedence(t *testing.T) {
+	kinds := map[string]string{
+		"exact":              "a",
+		"glob":               "a*",
+		"regexp":             "/^a$/",
+		"wildcard":           Wildcard,
+		"recursive wildcard": RecursiveWildcard,
+	}
+
+	for first, firstSegment := range kinds {
+		for second, secondSegment := range kinds {
+			if first == second {
+				continue
+			}
+
+			t.Run(first+" before "+second, func(t *testing.T) {
+				hs := NewHintSet().
+					Add([]string{firstSegment, "first"}).
+					Add([]string{secondSegment, "second"})
+
+				v, found := hs.SubHints("a").PeekActive()
+				assert.True(t, found)
+				assert.Equal(t, "first", v)
+			})
+		}
+	}
+}
+
+func TestHintSetPeekActiveSkipsPatterns(t *testing.T) {
+	for _, segment := range []string{Wildcard, RecursiveWildcard, "*_type", "/^type$/"} {
+		t.Run(segment, func(t *testing.T) {
+			hs := NewHintSet().Add([]string{segment}).Add([]string{"type"})
+
+			v, found := hs.PeekActive()
+			assert.True(t, found)
+			assert.Equal(t, "type", v)
+
+			v, found = compileHintSet(NewHintSet().Add([]string{segment})).peekActive()
+			assert.False(t, found)
+			assert.Empty(t, v)
+		})
+	}
+}
@@ -0,0 +1,430 @@
+// Package httpinfer provides a `net/http` middleware that infers schemas for
+// JSON request and response bodies per route from live traffic.
+package httpinfer
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	jtdinfer "github.com/bombsimon/jtd-infer-go"
+	"github.com/bombsimon/jtd-infer-go/internal/pathtemplate"
+)
+
+// DefaultMaxBodySize is the maximum body size used if not set in the options.
+const DefaultMaxBodySize = 1 << 20
+
+// DefaultMaxRoutes is the maximum number of routes used if not set in the
+// options.
+const DefaultMaxRoutes = 1000
+
+// Options holds the options for the middleware.
+type Options struct {
+	// MaxBodySize is the maximum size in bytes of a body to infer, larger
+	// bodies are skipped. Defaults to `DefaultMaxBodySize`.
+	MaxBodySize int64
+	// SampleRate is the fraction of requests, between 0 and 1, to infer.
+	// Defaults to 1 which infers all requests.
+	SampleRate float64
+	// Route returns the route to group the request by. Defaults to the method
+	// and the path where numeric and UUID segments are collapsed, e.g.
+	// `GET /users/{id}`. Prefer a route with a bounded number of values such
+	// as the pattern of the matched handler since every route is kept.
+	Route func(r *http.Request) string
+	// MaxRoutes is the maximum number of routes to infer schemas for, requests
+	// for new routes are skipped once reached. This bounds the memory when
+	// clients request random paths. Defaults to `DefaultMaxRoutes`.
+	MaxRoutes int
+	// Hints are used for all inferred schemas.
+	Hints jtdinfer.Hints
+}
+
+// RouteSchemas holds the inferred schemas for a route.
+type RouteSchemas struct {
+	Request   *jtdinfer.Schema        `json:"request,omitempty"`
+	Responses map[int]jtdinfer.Schema `json:"responses,omitempty"`
+}
+
+// Middleware infers schemas for JSON bodies per route. It's safe to use from
+// multiple goroutines.
+type Middleware struct {
+	opts   Options
+	random func() float64
+
+	// mu guards the routes, the inferrers are safe for concurrent use.
+	mu     sync.Mutex
+	routes map[string]*route
+}
+
+type route struct {
+	request   *jtdinfer.SyncInferrer
+	responses map[int]*jtdinfer.SyncInferrer
+}
+
+// New creates a new middleware.
+func New(opts Options) *Middleware {
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultMaxBodySize
+	}
+
+	if opts.SampleRate <= 0 {
+		opts.SampleRate = 1
+	}
+
+	if opts.Route == nil {
+		opts.Route = DefaultRoute
+	}
+
+	if opts.MaxRoutes <= 0 {
+		opts.MaxRoutes = DefaultMaxRoutes
+	}
+
+	return &Middleware{
+		opts:   opts,
+		random: rand.Float64,
+		routes: map[string]*route{},
+	}
+}
+
+// DefaultRoute returns the method and the path where numeric and UUID segments
+// are collapsed, e.g. `GET /users/{id}`.
+func DefaultRoute(r *http.Request) string {
+	return r.Method + " " + pathtemplate.Normalize(r.URL.Path)
+}
+
+// Handler wraps the handler and infers the JSON request and response bodies.
+// The bodies are copied while read and written so the handler and client see
+// them unchanged. Bodies that aren't JSON, are larger than the maximum size or
+// aren't fully read by the handler are skipped.
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.opts.SampleRate < 1 && m.random() >= m.opts.SampleRate {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var request *teeReader
+		if r.Body != nil && isJSON(r.Header.Get("Content-Type")) {
+			request = &teeReader{ReadCloser: r.Body, limit: m.opts.MaxBodySize}
+			r.Body = request
+		}
+
+		response := &teeWriter{ResponseWriter: w, limit: m.opts.MaxBodySize}
+		next.ServeHTTP(response.wrap(), r)
+
+		var requestBody, responseBody any
+
+		if request != nil {
+			requestBody, _ = decode(&request.buf, request.exceeded)
+		}
+
+		if isJSON(response.Header().Get("Content-Type")) {
+			responseBody, _ = decode(&response.buf, response.exceeded)
+		}
+
+		m.infer(m.opts.Route(r), requestBody, response.statusCode(), responseBody)
+	})
+}
+
+func (m *Middleware) infer(name string, request any, status int, response any) {
+	if request == nil && response == nil {
+		return
+	}
+
+	var requestInferrer, responseInferrer *jtdinfer.SyncInferrer
+
+	// The lock is only held to find or create the inferrers, values are
+	// observed concurrently.
+	m.mu.Lock()
+
+	rt, ok := m.routes[name]
+	if !ok {
+		if len(m.routes) >= m.opts.MaxRoutes {
+			m.mu.Unlock()
+			return
+		}
+
+		rt = &route{responses: map[int]*jtdinfer.SyncInferrer{}}
+		m.routes[name] = rt
+	}
+
+	if request != nil {
+		if rt.request == nil {
+			rt.request = jtdinfer.NewSyncInferrer(m.opts.Hints)
+		}
+
+		requestInferrer = rt.request
+	}
+
+	if response != nil {
+		if rt.responses[status] == nil {
+			rt.responses[status] = jtdinfer.NewSyncInferrer(m.opts.Hints)
+		}
+
+		responseInferrer = rt.responses[status]
+	}
+
+	m.mu.Unlock()
+
+	if requestInferrer != nil {
+		requestInferrer.Observe(request)
+	}
+
+	if responseInferrer != nil {
+		responseInferrer.Observe(response)
+	}
+}
+
+// Snapshot returns the currently inferred schemas per route. Inferrers that
+// haven't observed any value yet, because they were just created, are left
+// out.
+func (m *Middleware) Snapshot() map[string]RouteSchemas {
+	m.mu.Lock()
+
+	routes := make(map[string]route, len(m.routes))
+	for name, rt := range m.routes {
+		responses := make(map[int]*jtdinfer.SyncInferrer, len(rt.responses))
+		for status, inferrer := range rt.responses {
+			responses[status] = inferrer
+		}
+
+		routes[name] = route{request: rt.request, responses: responses}
+	}
+
+	m.mu.Unlock()
+
+	snapshot := make(map[string]RouteSchemas, len(routes))
+
+	for name, rt := range routes {
+		schemas := RouteSchemas{}
+
+		if schema, ok := observed(rt.request); ok {
+			schemas.Request = &schema
+		}
+
+		for status, inferrer := range rt.responses {
+			schema, ok := observed(inferrer)
+			if !ok {
+				continue
+			}
+
+			if schemas.Responses == nil {
+				schemas.Responses = make(map[int]jtdinfer.Schema, len(rt.responses))
+			}
+
+			schemas.Responses[status] = schema
+		}
+
+		if schemas.Request != nil || schemas.Responses != nil {
+			snapshot[name] = schemas
+		}
+	}
+
+	return snapshot
+}
+
+// observed returns the schema for the inferrer or false if it's nil or hasn't
+// observed any value.
+func observed(inferrer *jtdinfer.SyncInferrer) (jtdinfer.Schema, bool) {
+	if inferrer == nil {
+		return jtdinfer.Schema{}, false
+	}
+
+	merged := inferrer.Inferrer()
+	if merged.Inference.SchemaType == jtdinfer.SchemaTypeUnknown {
+		return jtdinfer.Schema{}, false
+	}
+
+	return merged.IntoSchema(), true
+}
+
+// Schemas returns the currently inferred schemas named after the route and
+// kind, e.g. `GetUsersIdRequest` and `GetUsersIdResponse200`, that can be
+// passed to the code generators. The names are unique, if several routes get
+// the same name a suffix such as `_2` is added in the order of the routes.
+func (m *Middleware) Schemas() map[string]jtdinfer.Schema {
+	snapshot := m.Snapshot()
+
+	routes := make([]string, 0, len(snapshot))
+	for name := range snapshot {
+		routes = append(routes, name)
+	}
+
+	sort.Strings(routes)
+
+	schemas := map[string]jtdinfer.Schema{}
+
+	add := func(name string, schema jtdinfer.Schema) {
+		unique := name
+		for i := 2; ; i++ {
+			if _, ok := schemas[unique]; !ok {
+				break
+			}
+
+			unique = name + "_" + strconv.Itoa(i)
+		}
+
+		schemas[unique] = schema
+	}
+
+	for _, name := range routes {
+		base := pathtemplate.Name(name)
+		rs := snapshot[name]
+
+		if rs.Request != nil {
+			add(base+"Request", *rs.Request)
+		}
+
+		statuses := make([]int, 0, len(rs.Responses))
+		for status := range rs.Responses {
+			statuses = append(statuses, status)
+		}
+
+		sort.Ints(statuses)
+
+		for _, status := range statuses {
+			add(base+"Response"+strconv.Itoa(status), rs.Responses[status])
+		}
+	}
+
+	return schemas
+}
+
+// ServeHTTP serves the snapshot as JSON keyed by route.
+func (m *Middleware) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(m.Snapshot()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// teeReader copies everything read up to the maximum size.
+type teeReader struct {
+	io.ReadCloser
+	buf      bytes.Buffer
+	limit    int64
+	exceeded bool
+}
+
+func (t *teeReader) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	t.exceeded = copyMax(&t.buf, p[:n], t.limit) || t.exceeded
+
+	return n, err
+}
+
+// teeWriter copies everything written up to the maximum size and records the
+// status code.
+type teeWriter struct {
+	http.ResponseWriter
+	buf      bytes.Buffer
+	limit    int64
+	exceeded bool
+	status   int
+}
+
+func (t *teeWriter) WriteHeader(statusCode int) {
+	if t.status == 0 {
+		t.status = statusCode
+	}
+
+	t.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (t *teeWriter) Write(p []byte) (int, error) {
+	n, err := t.ResponseWriter.Write(p)
+	t.exceeded = copyMax(&t.buf, p[:n], t.limit) || t.exceeded
+
+	return n, err
+}
+
+// Unwrap returns the wrapped writer so `http.ResponseController` can be used.
+func (t *teeWriter) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
+
+// wrap returns the writer implementing `http.Flusher` and `http.Hijacker` if
+// the wrapped writer does so handlers asserting them keep working.
+func (t *teeWriter) wrap() http.ResponseWriter {
+	flusher, isFlusher := t.ResponseWriter.(http.Flusher)
+	hijacker, isHijacker := t.ResponseWriter.(http.Hijacker)
+
+	switch {
+	case isFlusher && isHijacker:
+		return struct {
+			*teeWriter
+			http.Flusher
+			http.Hijacker
+		}{t, flusher, hijacker}
+	case isFlusher:
+		return struct {
+			*teeWriter
+			http.Flusher
+		}{t, flusher}
+	case isHijacker:
+		return struct {
+			*teeWriter
+			http.Hijacker
+		}{t, hijacker}
+	}
+
+	return t
+}
+
+func (t *teeWriter) statusCode() int {
+	if t.status == 0 {
+		return http.StatusOK
+	}
+
+	return t.status
+}
+
+// copyMax copies p to the buffer unless the buffer would exceed the maximum
+// size in which case true is returned.
+func copyMax(buf *bytes.Buffer, p []byte, limit int64) bool {
+	if int64(buf.Len()+len(p)) > limit {
+		return true
+	}
+
+	buf.Write(p)
+
+	return false
+}
+
+func decode(buf *bytes.Buffer, exceeded bool) (any, bool) {
+	if exceeded || buf.Len() == 0 {
+		return nil, false
+	}
+
+	decoder := json.NewDecoder(buf)
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, false
+	}
+
+	return value, true
+}
+
+// isJSON checks if the content type is `application/json` or has a `+json`
+// suffix.
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
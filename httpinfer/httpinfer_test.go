@@ -0,0 +1,259 @@
+package httpinfer
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	jtd "github.com/jsontypedef/json-typedef-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jtdinfer "github.com/bombsimon/jtd-infer-go"
+)
+
+func echoHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		if r.URL.Path == "/missing" {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error": "not found"}`))
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": 1, "body": `))
+		_, _ = w.Write(body)
+		_, _ = w.Write([]byte(`}`))
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	m := New(Options{})
+	server := httptest.NewServer(m.Handler(echoHandler()))
+
+	defer server.Close()
+
+	post := func(path, contentType, body string) string {
+		resp, err := http.Post(server.URL+path, contentType, strings.NewReader(body))
+		require.NoError(t, err)
+
+		defer resp.Body.Close()
+
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return string(b)
+	}
+
+	assert.Equal(t, `{"id": 1, "body": {"name": "Joe"}}`, post("/users/1", "application/json", `{"name": "Joe"}`))
+	post("/users/2", "application/json", `{"name": "Jane", "age": 30}`)
+	post("/missing", "application/json", `{"id": 1}`)
+	post("/text", "text/plain", `{"ignored": true}`)
+
+	snapshot := m.Snapshot()
+	require.Len(t, snapshot, 3)
+
+	users := snapshot["POST /users/{id}"]
+	assert.Equal(t, &jtdinfer.Schema{
+		Properties: map[string]jtdinfer.Schema{
+			"name": {Type: jtd.TypeString},
+		},
+		OptionalProperties: map[string]jtdinfer.Schema{
+			"age": {Type: jtd.TypeUint8},
+		},
+	}, users.Request)
+	assert.Contains(t, users.Responses, http.StatusOK)
+
+	assert.Equal(t, jtdinfer.Schema{
+		Properties: map[string]jtdinfer.Schema{
+			"error": {Type: jtd.TypeString},
+		},
+	}, snapshot["POST /missing"].Responses[http.StatusNotFound])
+
+	// The request isn't JSON but the response is.
+	assert.Nil(t, snapshot["POST /text"].Request)
+	assert.Contains(t, snapshot["POST /text"].Responses, http.StatusOK)
+
+	assert.Contains(t, m.Schemas(), "PostUsersIdRequest")
+	assert.Contains(t, m.Schemas(), "PostMissingResponse404")
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/schemas", nil))
+
+	var served map[string]RouteSchemas
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &served))
+	assert.Equal(t, snapshot, served)
+}
+
+func TestMiddlewareMaxBodySize(t *testing.T) {
+	m := New(Options{MaxBodySize: 10})
+	handler := m.Handler(echoHandler())
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name": "a long name"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, `{"id": 1, "body": {"name": "a long name"}}`, rec.Body.String())
+	assert.Empty(t, m.Snapshot())
+}
+
+func TestMiddlewareSampleRate(t *testing.T) {
+	m := New(Options{SampleRate: 0.5})
+
+	values := []float64{0.7, 0.2}
+	m.random = func() float64 {
+		v := values[0]
+		values = values[1:]
+
+		return v
+	}
+
+	handler := m.Handler(echoHandler())
+
+	for _, path := range []string{"/skipped", "/sampled"} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	snapshot := m.Snapshot()
+	assert.NotContains(t, snapshot, "POST /skipped")
+	assert.Contains(t, snapshot, "POST /sampled")
+}
+
+func TestMiddlewareConcurrent(t *testing.T) {
+	m := New(Options{})
+	handler := m.Handler(echoHandler())
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			req := httptest.NewRequest(http.MethodPost, "/users/1", strings.NewReader(`{"name": "Joe"}`))
+			req.Header.Set("Content-Type", "application/json")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			_ = m.Snapshot()
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, &jtdinfer.Schema{
+		Properties: map[string]jtdinfer.Schema{"name": {Type: jtd.TypeString}},
+	}, m.Snapshot()["POST /users/{id}"].Request)
+}
+
+func TestMiddlewareMaxRoutes(t *testing.T) {
+	m := New(Options{MaxRoutes: 2})
+	handler := m.Handler(echoHandler())
+
+	for _, path := range []string{"/a", "/b", "/c", "/a"} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	snapshot := m.Snapshot()
+	assert.Len(t, snapshot, 2)
+	assert.Contains(t, snapshot, "POST /a")
+	assert.Contains(t, snapshot, "POST /b")
+}
+
+func TestMiddlewareSchemaNameCollisions(t *testing.T) {
+	m := New(Options{})
+	handler := m.Handler(echoHandler())
+
+	for _, path := range []string{"/a-b", "/a/b"} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"path": "`+path+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	schemas := m.Schemas()
+	assert.Len(t, schemas, 4)
+	assert.Contains(t, schemas, "PostABRequest")
+	assert.Contains(t, schemas, "PostABRequest_2")
+	assert.Contains(t, schemas, "PostABResponse200")
+	assert.Contains(t, schemas, "PostABResponse200_2")
+}
+
+func TestMiddlewareSnapshotWithoutValues(t *testing.T) {
+	m := New(Options{})
+
+	// Inferrers are created before the first value is observed.
+	m.routes["GET /empty"] = &route{
+		request:   jtdinfer.NewSyncInferrer(jtdinfer.WithoutHints()),
+		responses: map[int]*jtdinfer.SyncInferrer{200: jtdinfer.NewSyncInferrer(jtdinfer.WithoutHints())},
+	}
+
+	assert.Empty(t, m.Snapshot())
+	assert.Empty(t, m.Schemas())
+}
+
+// hijackRecorder is a recorder that also implements `http.Hijacker`.
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (*hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, http.ErrNotSupported
+}
+
+func TestMiddlewareOptionalInterfaces(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		writer      http.ResponseWriter
+		flusher     bool
+		hijacker    bool
+	}{
+		{
+			description: "flusher",
+			writer:      httptest.NewRecorder(),
+			flusher:     true,
+		},
+		{
+			description: "flusher and hijacker",
+			writer:      &hijackRecorder{httptest.NewRecorder()},
+			flusher:     true,
+			hijacker:    true,
+		},
+		{
+			description: "neither",
+			writer:      struct{ http.ResponseWriter }{httptest.NewRecorder()},
+		},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			var isFlusher, isHijacker bool
+
+			handler := New(Options{}).Handler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, isFlusher = w.(http.Flusher)
+				_, isHijacker = w.(http.Hijacker)
+
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{}`))
+			}))
+
+			handler.ServeHTTP(tc.writer, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tc.flusher, isFlusher)
+			assert.Equal(t, tc.hijacker, isHijacker)
+		})
+	}
+}
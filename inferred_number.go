@@ -1,7 +1,13 @@
 package jtdinfer
 
 import (
+	"encoding/json"
+	"errors"
 	"math"
+	"math/big"
+	"math/bits"
+	"strconv"
+	"strings"
 
 	jtd "github.com/jsontypedef/json-typedef-go"
 )
@@ -10,9 +16,12 @@ import (
 // JTD.
 type NumType uint8
 
-// Available number types.
+// Available number types. `NumTypeUnset` is the zero value and means that no
+// default number type is used, instead the smallest type that can hold all the
+// seen numbers is picked.
 const (
-	NumTypeUint8 NumType = iota
+	NumTypeUnset NumType = iota
+	NumTypeUint8
 	NumTypeInt8
 	NumTypeUint16
 	NumTypeInt16
@@ -27,6 +36,28 @@ func (n NumType) IsFloat() bool {
 	return n == NumTypeFloat32 || n == NumTypeFloat64
 }
 
+// IsUnsigned returns true if the `NumType` is an unsigned integer.
+func (n NumType) IsUnsigned() bool {
+	return n == NumTypeUint8 || n == NumTypeUint16 || n == NumTypeUint32
+}
+
+// Bits returns the bit width of the `NumType`.
+func (n NumType) Bits() int {
+	switch n {
+	case NumTypeUint8, NumTypeInt8:
+		return 8
+	case NumTypeUint16, NumTypeInt16:
+		return 16
+	case NumTypeUint32, NumTypeInt32, NumTypeFloat32:
+		return 32
+	case NumTypeFloat64:
+		return 64
+	case NumTypeUnset:
+	}
+
+	return 0
+}
+
 // AsRange returns the maximum and minimum value for a `NumType`.
 func (n NumType) AsRange() (float64, float64) {
 	switch n {
@@ -44,12 +75,14 @@ func (n NumType) AsRange() (float64, float64) {
 		return math.MinInt32, math.MaxInt32
 	case NumTypeFloat32, NumTypeFloat64:
 		return -math.MaxFloat64, math.MaxFloat64
+	case NumTypeUnset:
 	}
 
 	return 0, 0
 }
 
-// IntoType will convert a `NumType` to a `jtd.Type`.
+// IntoType will convert a `NumType` to a `jtd.Type`. `NumTypeUnset` has no type
+// and returns the empty type.
 func (n NumType) IntoType() jtd.Type {
 	switch n {
 	case NumTypeUint8:
@@ -68,60 +101,183 @@ func (n NumType) IntoType() jtd.Type {
 		return jtd.TypeFloat32
 	case NumTypeFloat64:
 		return jtd.TypeFloat64
+	case NumTypeUnset:
+	}
+
+	return ""
+}
+
+// NumberPolicy controls which number types are allowed when selecting the type
+// for a number. The zero value allows all types except `float32`.
+type NumberPolicy struct {
+	// PreferSigned will never select an unsigned integer type.
+	PreferSigned bool
+	// MinWidth is the minimum bit width for integer types, e.g. 32 to always
+	// select at least `int32` or `uint32`.
+	MinWidth int
+	// AllowFloat32 will select `float32` instead of `float64` if all seen
+	// numbers can be represented exactly as a `float32`.
+	AllowFloat32 bool
+}
+
+// Allows checks if the `NumType` can be selected with the policy.
+func (p NumberPolicy) Allows(nt NumType) bool {
+	if p.PreferSigned && nt.IsUnsigned() {
+		return false
 	}
 
-	return jtd.TypeUint8
+	return nt.IsFloat() || nt.Bits() >= p.MinWidth
 }
 
+// BigIntPolicy decides how integers that doesn't fit in any JTD integer type
+// are represented in the schema.
+type BigIntPolicy uint8
+
+// Available big integer policies.
+const (
+	// BigIntAsFloat64 will represent big integers as `float64`. This is the
+	// default.
+	BigIntAsFloat64 BigIntPolicy = iota
+	// BigIntAsString will represent big integers as `string` with a `format`
+	// note in the metadata. This is useful for APIs that transmits 64-bit
+	// integers as strings.
+	BigIntAsString
+)
+
 // InferredNumber represents the state for a column that is a number. It holds
 // the seen maximum and minimum value together with information about if all
-// seen numbers are integers.
+// seen numbers are integers and if all seen numbers can be represented exactly
+// as a `float32`. As long as all numbers are integers the exact bounds are also
+// kept in `IntMin` and `IntMax` since a `float64` can't represent integers
+// above 2^53 without losing precision.
+//
+// The range is set by the first seen number so `Min` and `Max` are only valid
+// if `Count` is above zero. `Count` and `Sum` only includes finite numbers,
+// NaN and infinity are counted in `NonFinite` and doesn't affect the range.
 type InferredNumber struct {
 	Min       float64
 	Max       float64
+	Count     int
+	Sum       float64
+	NonFinite int
 	IsInteger bool
+	IsFloat32 bool
+	IntMin    *big.Int
+	IntMax    *big.Int
 }
 
 // NewNumber will return a new `InferredNumber`.
 func NewNumber() *InferredNumber {
 	return &InferredNumber{
 		IsInteger: true,
+		IsFloat32: true,
 	}
 }
 
 // Infer will infer a value, updating the state for the `InferredNumber`.
 func (i *InferredNumber) Infer(n float64) *InferredNumber {
-	return &InferredNumber{
-		Min:       math.Min(i.Min, n),
-		Max:       math.Max(i.Max, n),
-		IsInteger: i.IsInteger && float64(int(n)) == n,
+	return i.inferNumber(numberFromFloat(n))
+}
+
+func (i *InferredNumber) inferNumber(n number) *InferredNumber {
+	inferred := *i
+	inferred.update(n)
+
+	return &inferred
+}
+
+// update updates the state in place with the number. The bounds are replaced
+// and never modified so they can be shared with copies of the state.
+func (i *InferredNumber) update(n number) {
+	// NaN and infinity can't be represented in JSON and is never an integer.
+	// They are only counted to not poison the range.
+	if !n.isInteger() && (math.IsNaN(n.float) || math.IsInf(n.float, 0)) {
+		i.NonFinite++
+		i.IsInteger = false
+		i.IntMin, i.IntMax = nil, nil
+
+		return
+	}
+
+	if i.Count == 0 {
+		i.Min, i.Max = n.float, n.float
+	} else {
+		i.Min = math.Min(i.Min, n.float)
+		i.Max = math.Max(i.Max, n.float)
+	}
+
+	i.Count++
+	i.Sum += n.float
+	i.IsInteger = i.IsInteger && n.isInteger()
+	i.IsFloat32 = i.IsFloat32 && n.isFloat32()
+
+	if !i.IsInteger {
+		i.IntMin, i.IntMax = nil, nil
+		return
+	}
+
+	if i.IntMin == nil || n.cmp(i.IntMin) < 0 {
+		i.IntMin = n.bigInt()
+	}
+
+	if i.IntMax == nil || n.cmp(i.IntMax) > 0 {
+		i.IntMax = n.bigInt()
 	}
 }
 
-// IntoType will convert an `InferredNumber` to a `jtd.Type`.
-func (i *InferredNumber) IntoType(defaultType NumType) jtd.Type {
-	if i.ContainedBy(defaultType) {
-		return defaultType.IntoType()
+// Mean returns the mean of all seen finite numbers. The returned boolean is
+// false if no finite number has been seen.
+func (i *InferredNumber) Mean() (float64, bool) {
+	if i.Count == 0 {
+		return 0, false
 	}
 
-	numTypes := []NumType{
-		NumTypeUint8,
-		NumTypeInt8,
-		NumTypeUint16,
-		NumTypeInt16,
-		NumTypeUint32,
-		NumTypeInt32,
+	return i.Sum / float64(i.Count), true
+}
+
+// IntoType will convert an `InferredNumber` to a `jtd.Type`. The default type
+// is used if it can hold all seen numbers, otherwise the smallest integer type
+// that can hold them is used before falling back to `float64`.
+func (i *InferredNumber) IntoType(defaultType NumType) jtd.Type {
+	return i.IntoTypeWithPolicy(defaultType, NumberPolicy{})
+}
+
+// IntoTypeWithPolicy works like `IntoType` but will only select number types
+// allowed by the `NumberPolicy`.
+func (i *InferredNumber) IntoTypeWithPolicy(defaultType NumType, policy NumberPolicy) jtd.Type {
+	if defaultType != NumTypeUnset && policy.Allows(defaultType) && i.ContainedBy(defaultType) {
+		return defaultType.IntoType()
 	}
 
-	for _, v := range numTypes {
-		if i.ContainedBy(v) {
+	for _, v := range integerNumTypes() {
+		if policy.Allows(v) && i.ContainedBy(v) {
 			return v.IntoType()
 		}
 	}
 
+	if policy.AllowFloat32 && i.IsFloat32 {
+		return jtd.TypeFloat32
+	}
+
 	return jtd.TypeFloat64
 }
 
+// IntoSchema will convert an `InferredNumber` to a `Schema`. Integers that
+// doesn't fit in any JTD integer type are represented according to the
+// `BigIntPolicy` in the hints.
+func (i *InferredNumber) IntoSchema(hints Hints) Schema {
+	if hints.BigInt == BigIntAsString && i.IsInteger && !i.fitsInteger(hints.NumberPolicy) {
+		return Schema{
+			Type: jtd.TypeString,
+			Metadata: map[string]any{
+				"format": i.integerFormat(),
+			},
+		}
+	}
+
+	return Schema{Type: i.IntoTypeWithPolicy(hints.DefaultNumType, hints.NumberPolicy)}
+}
+
 // ContainedBy checks if an inferred number column can be contained within the
 // passed `NumType`, meaning it is above the minimum and below the maximum value
 // for the number type.
@@ -135,33 +291,230 @@ func (i *InferredNumber) ContainedBy(nt NumType) bool {
 	return minValue <= i.Min && maxValue >= i.Max
 }
 
-func anyAsNumber(value any) (float64, bool) {
+func (i *InferredNumber) fitsInteger(policy NumberPolicy) bool {
+	for _, v := range integerNumTypes() {
+		if policy.Allows(v) && i.ContainedBy(v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// integerFormat returns the name of the smallest 64-bit integer type that can
+// hold all seen integers, or "bigint" if none can.
+func (i *InferredNumber) integerFormat() string {
+	intMin, intMax := orZero(i.IntMin), orZero(i.IntMax)
+
+	switch {
+	case intMin.IsInt64() && intMax.IsInt64():
+		return "int64"
+	case intMin.Sign() >= 0 && intMax.IsUint64():
+		return "uint64"
+	default:
+		return "bigint"
+	}
+}
+
+func integerNumTypes() []NumType {
+	return []NumType{
+		NumTypeUint8,
+		NumTypeInt8,
+		NumTypeUint16,
+		NumTypeInt16,
+		NumTypeUint32,
+		NumTypeInt32,
+	}
+}
+
+// number is a parsed numeric value. If the value is an integer the exact value,
+// which might not be representable as a `float64`, is kept in `small` if it
+// fits in an `int64` and otherwise in `integer` to not allocate a `big.Int` for
+// every number.
+type number struct {
+	float   float64
+	small   int64
+	isSmall bool
+	integer *big.Int
+}
+
+// isInteger returns true if the number is an integer.
+func (n number) isInteger() bool {
+	return n.isSmall || n.integer != nil
+}
+
+// bigInt returns the integer as a new `big.Int` unless it already is one.
+func (n number) bigInt() *big.Int {
+	if n.integer != nil {
+		return n.integer
+	}
+
+	return big.NewInt(n.small)
+}
+
+// cmp compares the integer with v the same way as `big.Int.Cmp`.
+func (n number) cmp(v *big.Int) int {
+	switch {
+	case n.integer != nil:
+		return n.integer.Cmp(v)
+	case !v.IsInt64():
+		return -v.Sign()
+	case n.small < v.Int64():
+		return -1
+	case n.small > v.Int64():
+		return 1
+	default:
+		return 0
+	}
+}
+
+// isFloat32 checks if the number can be represented exactly as a `float32`.
+func (n number) isFloat32() bool {
+	if n.isSmall {
+		abs := uint64(n.small)
+		if n.small < 0 {
+			abs = -abs
+		}
+
+		// A `float32` has 24 significant bits.
+		return abs == 0 || bits.Len64(abs)-bits.TrailingZeros64(abs) <= 24
+	}
+
+	if n.integer != nil {
+		_, accuracy := new(big.Float).SetInt(n.integer).Float32()
+		return accuracy == big.Exact
+	}
+
+	return float64(float32(n.float)) == n.float
+}
+
+func numberFromFloat(f float64) number {
+	n := number{float: f}
+
+	switch {
+	case math.IsInf(f, 0) || math.IsNaN(f) || math.Trunc(f) != f:
+	case f >= math.MinInt64 && f < math.MaxInt64:
+		n.small, n.isSmall = int64(f), true
+	default:
+		n.integer, _ = big.NewFloat(f).Int(nil)
+	}
+
+	return n
+}
+
+// numberFromString parses a JSON number literal. Plain integer literals are
+// parsed exactly, while literals with a fraction or exponent are parsed as a
+// `float64` and considered integers if they have no fractional part.
+func numberFromString(s string) (number, bool) {
+	if !strings.ContainsAny(s, ".eE") {
+		if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return numberFromInt64(v), true
+		}
+
+		if v, ok := new(big.Int).SetString(s, 10); ok {
+			f, _ := new(big.Float).SetInt(v).Float64()
+			return number{float: f, integer: v}, true
+		}
+
+		return number{}, false
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return number{}, false
+	}
+
+	return numberFromFloat(f), true
+}
+
+func minInt(a, b *big.Int) *big.Int {
+	if a != nil && a.Cmp(b) <= 0 {
+		return a
+	}
+
+	return b
+}
+
+func maxInt(a, b *big.Int) *big.Int {
+	if a != nil && a.Cmp(b) >= 0 {
+		return a
+	}
+
+	return b
+}
+
+// orZero returns the passed integer or zero if it's nil, which is the case
+// before any integer has been seen.
+func orZero(v *big.Int) *big.Int {
+	if v == nil {
+		return new(big.Int)
+	}
+
+	return v
+}
+
+func anyAsNumber(value any) (number, bool) {
 	switch v := value.(type) {
 	case float64:
-		return v, true
+		return numberFromFloat(v), true
 	case float32:
-		return float64(v), true
+		return numberFromFloat(float64(v)), true
 	case uint:
-		return float64(v), true
+		return numberFromUint64(uint64(v)), true
 	case uint8:
-		return float64(v), true
+		return numberFromUint64(uint64(v)), true
 	case uint16:
-		return float64(v), true
+		return numberFromUint64(uint64(v)), true
 	case uint32:
-		return float64(v), true
+		return numberFromUint64(uint64(v)), true
 	case uint64:
-		return float64(v), true
+		return numberFromUint64(v), true
 	case int:
-		return float64(v), true
+		return numberFromInt64(int64(v)), true
 	case int8:
-		return float64(v), true
+		return numberFromInt64(int64(v)), true
 	case int16:
-		return float64(v), true
+		return numberFromInt64(int64(v)), true
 	case int32:
-		return float64(v), true
+		return numberFromInt64(int64(v)), true
 	case int64:
-		return float64(v), true
+		return numberFromInt64(v), true
+	case json.Number:
+		return numberFromString(v.String())
+	case *big.Int:
+		if v == nil {
+			return number{}, false
+		}
+
+		f, _ := new(big.Float).SetInt(v).Float64()
+
+		return number{float: f, integer: new(big.Int).Set(v)}, true
+	case *big.Float:
+		if v == nil {
+			return number{}, false
+		}
+
+		f, _ := v.Float64()
+		if !v.IsInt() {
+			return number{float: f}, true
+		}
+
+		integer, _ := v.Int(nil)
+
+		return number{float: f, integer: integer}, true
 	default:
-		return 0.0, false
+		return number{}, false
+	}
+}
+
+func numberFromInt64(v int64) number {
+	return number{float: float64(v), small: v, isSmall: true}
+}
+
+func numberFromUint64(v uint64) number {
+	if v <= math.MaxInt64 {
+		return numberFromInt64(int64(v))
 	}
+
+	return number{float: float64(v), integer: new(big.Int).SetUint64(v)}
 }
@@ -1,11 +1,14 @@
 package jtdinfer
 
 import (
+	"encoding/json"
 	"math"
+	"math/big"
 	"testing"
 
 	jtd "github.com/jsontypedef/json-typedef-go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestInferredNumberDefault(t *testing.T) {
@@ -66,3 +69,323 @@ func TestInferredNumberDefault(t *testing.T) {
 		})
 	}
 }
+
+func TestNumTypeIntoType(t *testing.T) {
+	assert.Equal(t, jtd.Type(""), NumTypeUnset.IntoType())
+	assert.Equal(t, jtd.Type(jtd.TypeUint8), NumTypeUint8.IntoType())
+	assert.Equal(t, jtd.Type(jtd.TypeFloat64), NumTypeFloat64.IntoType())
+}
+
+func TestInferredNumberExactIntegers(t *testing.T) {
+	maxInt64 := NewNumber()
+	for _, v := range []any{int64(math.MaxInt64), int64(math.MinInt64)} {
+		n, ok := anyAsNumber(v)
+		require.True(t, ok)
+
+		maxInt64 = maxInt64.inferNumber(n)
+	}
+
+	assert.True(t, maxInt64.IsInteger)
+	assert.Equal(t, big.NewInt(math.MinInt64), maxInt64.IntMin)
+	assert.Equal(t, big.NewInt(math.MaxInt64), maxInt64.IntMax)
+
+	for _, tc := range []struct {
+		description string
+		value       any
+		expected    string
+	}{
+		{"uint64", uint64(math.MaxUint64), "18446744073709551615"},
+		{"json number above 2^53", json.Number("9007199254740993"), "9007199254740993"},
+		{"json number above uint64", json.Number("18446744073709551616"), "18446744073709551616"},
+		{"json number with exponent", json.Number("1e3"), "1000"},
+		{"big int", new(big.Int).Lsh(big.NewInt(1), 100), "1267650600228229401496703205376"},
+		{"big float", big.NewFloat(1 << 60), "1152921504606846976"},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			n, ok := anyAsNumber(tc.value)
+			require.True(t, ok)
+
+			inferred := NewNumber().inferNumber(n)
+			assert.True(t, inferred.IsInteger)
+			assert.Equal(t, tc.expected, inferred.IntMax.String())
+		})
+	}
+
+	for _, value := range []any{json.Number("1.5"), big.NewFloat(0.5)} {
+		n, ok := anyAsNumber(value)
+		require.True(t, ok)
+
+		inferred := NewNumber().inferNumber(n)
+		assert.False(t, inferred.IsInteger)
+		assert.Nil(t, inferred.IntMax)
+	}
+}
+
+func TestInferredNumberBigIntPolicy(t *testing.T) {
+	cases := []struct {
+		description    string
+		values         []any
+		policy         BigIntPolicy
+		expectedSchema Schema
+	}{
+		{
+			description:    "fits in integer type",
+			values:         []any{int64(math.MaxUint32)},
+			policy:         BigIntAsString,
+			expectedSchema: Schema{Type: jtd.TypeUint32},
+		},
+		{
+			description:    "int64 as float64",
+			values:         []any{int64(math.MaxInt64)},
+			policy:         BigIntAsFloat64,
+			expectedSchema: Schema{Type: jtd.TypeFloat64},
+		},
+		{
+			description: "int64 as string",
+			values:      []any{int64(math.MinInt64), int64(math.MaxInt64)},
+			policy:      BigIntAsString,
+			expectedSchema: Schema{
+				Type:     jtd.TypeString,
+				Metadata: map[string]any{"format": "int64"},
+			},
+		},
+		{
+			description: "uint64 as string",
+			values:      []any{uint64(math.MaxUint64)},
+			policy:      BigIntAsString,
+			expectedSchema: Schema{
+				Type:     jtd.TypeString,
+				Metadata: map[string]any{"format": "uint64"},
+			},
+		},
+		{
+			description: "big int as string",
+			values:      []any{json.Number("-18446744073709551616")},
+			policy:      BigIntAsString,
+			expectedSchema: Schema{
+				Type:     jtd.TypeString,
+				Metadata: map[string]any{"format": "bigint"},
+			},
+		},
+		{
+			description:    "non integers are not affected",
+			values:         []any{int64(math.MaxInt64), 0.5},
+			policy:         BigIntAsString,
+			expectedSchema: Schema{Type: jtd.TypeFloat64},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			inferred := NewNumber()
+
+			for _, v := range tc.values {
+				n, ok := anyAsNumber(v)
+				require.True(t, ok)
+
+				inferred = inferred.inferNumber(n)
+			}
+
+			assert.Equal(t, tc.expectedSchema, inferred.IntoSchema(Hints{BigInt: tc.policy}))
+		})
+	}
+}
+
+func TestInferredNumberPolicy(t *testing.T) {
+	cases := []struct {
+		description string
+		values      []float64
+		defaultType NumType
+		policy      NumberPolicy
+		jtdType     jtd.Type
+	}{
+		{
+			description: "unset default picks smallest type",
+			values:      []float64{1, 200},
+			jtdType:     jtd.TypeUint8,
+		},
+		{
+			description: "prefer signed",
+			values:      []float64{1, 200},
+			policy:      NumberPolicy{PreferSigned: true},
+			jtdType:     jtd.TypeInt16,
+		},
+		{
+			description: "prefer signed ignores unsigned default",
+			values:      []float64{1, 200},
+			defaultType: NumTypeUint32,
+			policy:      NumberPolicy{PreferSigned: true},
+			jtdType:     jtd.TypeInt16,
+		},
+		{
+			description: "prefer signed falls back to float",
+			values:      []float64{math.MaxUint32},
+			policy:      NumberPolicy{PreferSigned: true},
+			jtdType:     jtd.TypeFloat64,
+		},
+		{
+			description: "minimum width",
+			values:      []float64{1, 200},
+			policy:      NumberPolicy{MinWidth: 32},
+			jtdType:     jtd.TypeUint32,
+		},
+		{
+			description: "minimum width prefer signed",
+			values:      []float64{1, 200},
+			policy:      NumberPolicy{MinWidth: 32, PreferSigned: true},
+			jtdType:     jtd.TypeInt32,
+		},
+		{
+			description: "minimum width ignores smaller default",
+			values:      []float64{1, 200},
+			defaultType: NumTypeInt8,
+			policy:      NumberPolicy{MinWidth: 16},
+			jtdType:     jtd.TypeUint16,
+		},
+		{
+			description: "float32 not allowed",
+			values:      []float64{0.5, 1.25},
+			jtdType:     jtd.TypeFloat64,
+		},
+		{
+			description: "float32 allowed",
+			values:      []float64{0.5, 1.25},
+			policy:      NumberPolicy{AllowFloat32: true},
+			jtdType:     jtd.TypeFloat32,
+		},
+		{
+			description: "float32 allowed but not exact",
+			values:      []float64{0.5, 0.1},
+			policy:      NumberPolicy{AllowFloat32: true},
+			jtdType:     jtd.TypeFloat64,
+		},
+		{
+			description: "float32 allowed for large exact integer",
+			values:      []float64{1 << 40},
+			policy:      NumberPolicy{AllowFloat32: true},
+			jtdType:     jtd.TypeFloat32,
+		},
+		{
+			description: "float32 allowed but integer not exact",
+			values:      []float64{1<<40 + 1},
+			policy:      NumberPolicy{AllowFloat32: true},
+			jtdType:     jtd.TypeFloat64,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			inferred := NewNumber()
+			for _, v := range tc.values {
+				inferred = inferred.Infer(v)
+			}
+
+			assert.Equal(t, tc.jtdType, inferred.IntoTypeWithPolicy(tc.defaultType, tc.policy))
+		})
+	}
+}
+
+func TestInferredNumberPolicyWithBigInt(t *testing.T) {
+	hints := Hints{
+		BigInt:       BigIntAsString,
+		NumberPolicy: NumberPolicy{PreferSigned: true},
+	}
+
+	expectedSchema := Schema{
+		Type:     jtd.TypeString,
+		Metadata: map[string]any{"format": "int64"},
+	}
+
+	inferred := NewNumber().Infer(math.MaxUint32)
+	assert.Equal(t, expectedSchema, inferred.IntoSchema(hints))
+}
+
+func TestInferredNumberRange(t *testing.T) {
+	cases := []struct {
+		description string
+		values      []float64
+		min         float64
+		max         float64
+		count       int
+		sum         float64
+		nonFinite   int
+		isInteger   bool
+	}{
+		{
+			description: "nothing seen",
+			isInteger:   true,
+		},
+		{
+			description: "first value sets range",
+			values:      []float64{150, 100, 200},
+			min:         100,
+			max:         200,
+			count:       3,
+			sum:         450,
+			isInteger:   true,
+		},
+		{
+			description: "all negative",
+			values:      []float64{-3, -10},
+			min:         -10,
+			max:         -3,
+			count:       2,
+			sum:         -13,
+			isInteger:   true,
+		},
+		{
+			description: "nan is ignored",
+			values:      []float64{math.NaN(), 2, math.NaN(), 4},
+			min:         2,
+			max:         4,
+			count:       2,
+			sum:         6,
+			nonFinite:   2,
+		},
+		{
+			description: "infinity is ignored",
+			values:      []float64{math.Inf(1), 1.5, math.Inf(-1)},
+			min:         1.5,
+			max:         1.5,
+			count:       1,
+			sum:         1.5,
+			nonFinite:   2,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			inferred := NewNumber()
+			for _, v := range tc.values {
+				inferred = inferred.Infer(v)
+			}
+
+			assert.Equal(t, tc.min, inferred.Min)
+			assert.Equal(t, tc.max, inferred.Max)
+			assert.Equal(t, tc.count, inferred.Count)
+			assert.Equal(t, tc.sum, inferred.Sum)
+			assert.Equal(t, tc.nonFinite, inferred.NonFinite)
+			assert.Equal(t, tc.isInteger, inferred.IsInteger)
+
+			mean, ok := inferred.Mean()
+			assert.Equal(t, tc.count > 0, ok)
+
+			if ok {
+				assert.Equal(t, tc.sum/float64(tc.count), mean)
+			}
+		})
+	}
+}
+
+func TestInferredNumberExactRange(t *testing.T) {
+	inferred := NewNumber()
+	for _, v := range []string{"9007199254740993", "9007199254740995"} {
+		n, ok := anyAsNumber(json.Number(v))
+		require.True(t, ok)
+
+		inferred = inferred.inferNumber(n)
+	}
+
+	assert.Equal(t, "9007199254740993", inferred.IntMin.String())
+	assert.Equal(t, "9007199254740995", inferred.IntMax.String())
+}
@@ -0,0 +1,201 @@
+package jtdinfer
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+
+	jtd "github.com/jsontypedef/json-typedef-go"
+)
+
+// NumericStringPolicy decides if strings containing integers or decimals should
+// be detected and how they should be represented in the schema.
+type NumericStringPolicy uint8
+
+// Available numeric string policies.
+const (
+	// NumericStringsIgnore will treat numeric strings like any other string.
+	// This is the default.
+	NumericStringsIgnore NumericStringPolicy = iota
+	// NumericStringsAnnotate will keep the `string` type but add the range and
+	// precision of the numbers to the schema metadata.
+	NumericStringsAnnotate
+	// NumericStringsConvert will convert the string to a number type based on
+	// the range of the seen numbers.
+	NumericStringsConvert
+)
+
+// InferredNumericString represents the state for a string column where all
+// seen values are integers or decimals. The range is tracked the same way as
+// for numbers and the precision is tracked as the maximum number of digits
+// before and after the decimal point. Since a `float64` can't represent long
+// decimals exactly the exact bounds are also kept in `Min` and `Max`.
+type InferredNumericString struct {
+	Number        *InferredNumber
+	IntegerDigits int
+	Scale         int
+	Min           *big.Rat
+	Max           *big.Rat
+}
+
+// NewNumericString will return a new `InferredNumericString`.
+func NewNumericString() *InferredNumericString {
+	return &InferredNumericString{
+		Number: NewNumber(),
+	}
+}
+
+// Infer will infer a string, updating the state for the
+// `InferredNumericString`. If the string isn't an integer or a decimal nil is
+// returned since the column is no longer numeric.
+func (i *InferredNumericString) Infer(v string) *InferredNumericString {
+	integerDigits, scale, ok := parseDecimal(v)
+	if !ok {
+		return nil
+	}
+
+	n, ok := numberFromString(v)
+	if !ok {
+		return nil
+	}
+
+	r, ok := new(big.Rat).SetString(v)
+	if !ok {
+		return nil
+	}
+
+	return &InferredNumericString{
+		Number:        i.Number.inferNumber(n),
+		IntegerDigits: max(i.IntegerDigits, integerDigits),
+		Scale:         max(i.Scale, scale),
+		Min:           minRat(i.Min, r),
+		Max:           maxRat(i.Max, r),
+	}
+}
+
+// Precision returns the total number of digits needed to represent all seen
+// values, the same way precision is defined for SQL decimals.
+func (i *InferredNumericString) Precision() int {
+	return max(i.IntegerDigits+i.Scale, 1)
+}
+
+// IntoSchema will convert an `InferredNumericString` to a `Schema` according
+// to the `NumericStringPolicy` in the hints.
+func (i *InferredNumericString) IntoSchema(hints Hints) Schema {
+	switch hints.NumericStrings {
+	case NumericStringsIgnore:
+		return Schema{Type: jtd.TypeString}
+	case NumericStringsConvert:
+		return i.Number.IntoSchema(hints)
+	case NumericStringsAnnotate:
+	}
+
+	numericType := "decimal"
+	minValue := json.Number(formatDecimal(i.Min, i.Scale))
+	maxValue := json.Number(formatDecimal(i.Max, i.Scale))
+
+	if i.Scale == 0 && i.Number.IsInteger {
+		numericType = "integer"
+		minValue = json.Number(orZero(i.Number.IntMin).String())
+		maxValue = json.Number(orZero(i.Number.IntMax).String())
+	}
+
+	return Schema{
+		Type: jtd.TypeString,
+		Metadata: map[string]any{
+			"numericString": map[string]any{
+				"type":      numericType,
+				"min":       minValue,
+				"max":       maxValue,
+				"precision": i.Precision(),
+				"scale":     i.Scale,
+			},
+		},
+	}
+}
+
+// parseDecimal checks if the string is an integer or a decimal on the form
+// `-?(0|[1-9][0-9]*)(\.[0-9]+)?` and returns the number of significant digits
+// before and after the decimal point. Leading zeros are not allowed since
+// strings such as zip codes or identifiers like "007" would lose information
+// if treated as numbers.
+func parseDecimal(s string) (int, int, bool) {
+	if len(s) > 0 && s[0] == '-' {
+		s = s[1:]
+	}
+
+	integerPart, fractionPart := s, ""
+	hasFraction := false
+
+	for idx := 0; idx < len(s); idx++ {
+		if s[idx] == '.' {
+			integerPart, fractionPart = s[:idx], s[idx+1:]
+			hasFraction = true
+
+			break
+		}
+	}
+
+	if !isDigits(integerPart) || (hasFraction && !isDigits(fractionPart)) {
+		return 0, 0, false
+	}
+
+	if integerPart == "0" {
+		return 0, len(fractionPart), true
+	}
+
+	if integerPart[0] == '0' {
+		return 0, 0, false
+	}
+
+	return len(integerPart), len(fractionPart), true
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for idx := 0; idx < len(s); idx++ {
+		if s[idx] < '0' || s[idx] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// formatDecimal formats the value with at most scale digits after the decimal
+// point. All seen values have at most scale digits so the result is exact.
+func formatDecimal(r *big.Rat, scale int) string {
+	if r == nil {
+		return "0"
+	}
+
+	s := r.FloatString(scale)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+
+	if s == "-0" {
+		return "0"
+	}
+
+	return s
+}
+
+func minRat(a, b *big.Rat) *big.Rat {
+	if a != nil && (b == nil || a.Cmp(b) <= 0) {
+		return a
+	}
+
+	return b
+}
+
+func maxRat(a, b *big.Rat) *big.Rat {
+	if a != nil && (b == nil || a.Cmp(b) >= 0) {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,182 @@
+package jtdinfer
+
+import (
+	"encoding/json"
+	"testing"
+
+	jtd "github.com/jsontypedef/json-typedef-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDecimal(t *testing.T) {
+	cases := []struct {
+		value         string
+		integerDigits int
+		scale         int
+		ok            bool
+	}{
+		{"0", 0, 0, true},
+		{"12", 2, 0, true},
+		{"-12", 2, 0, true},
+		{"12.50", 2, 2, true},
+		{"0.125", 0, 3, true},
+		{"-0.5", 0, 1, true},
+		{"9007199254740993", 16, 0, true},
+		{"", 0, 0, false},
+		{"-", 0, 0, false},
+		{"007", 0, 0, false},
+		{"1.", 0, 0, false},
+		{".5", 0, 0, false},
+		{"1e3", 0, 0, false},
+		{"+1", 0, 0, false},
+		{"1,5", 0, 0, false},
+		{"12a", 0, 0, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.value, func(t *testing.T) {
+			integerDigits, scale, ok := parseDecimal(tc.value)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.integerDigits, integerDigits)
+			assert.Equal(t, tc.scale, scale)
+		})
+	}
+}
+
+func TestInferNumericStrings(t *testing.T) {
+	cases := []struct {
+		description    string
+		values         []string
+		policy         NumericStringPolicy
+		expectedSchema Schema
+	}{
+		{
+			description:    "ignored by default",
+			values:         []string{`"12"`, `"13"`},
+			policy:         NumericStringsIgnore,
+			expectedSchema: Schema{Type: jtd.TypeString},
+		},
+		{
+			description: "integers",
+			values:      []string{`"9007199254740993"`, `"-3"`},
+			policy:      NumericStringsAnnotate,
+			expectedSchema: Schema{
+				Type: jtd.TypeString,
+				Metadata: map[string]any{
+					"numericString": map[string]any{
+						"type":      "integer",
+						"min":       json.Number("-3"),
+						"max":       json.Number("9007199254740993"),
+						"precision": 16,
+						"scale":     0,
+					},
+				},
+			},
+		},
+		{
+			description: "decimals",
+			values:      []string{`"12.50"`, `"100"`, `"-0.125"`},
+			policy:      NumericStringsAnnotate,
+			expectedSchema: Schema{
+				Type: jtd.TypeString,
+				Metadata: map[string]any{
+					"numericString": map[string]any{
+						"type":      "decimal",
+						"min":       json.Number("-0.125"),
+						"max":       json.Number("100"),
+						"precision": 6,
+						"scale":     3,
+					},
+				},
+			},
+		},
+		{
+			description: "positive decimals",
+			values:      []string{`"12.50"`, `"100"`, `"0.125"`},
+			policy:      NumericStringsAnnotate,
+			expectedSchema: Schema{
+				Type: jtd.TypeString,
+				Metadata: map[string]any{
+					"numericString": map[string]any{
+						"type":      "decimal",
+						"min":       json.Number("0.125"),
+						"max":       json.Number("100"),
+						"precision": 6,
+						"scale":     3,
+					},
+				},
+			},
+		},
+		{
+			description: "long decimals",
+			values:      []string{`"0.12345678901234567890"`, `"-12345678901234567.5"`},
+			policy:      NumericStringsAnnotate,
+			expectedSchema: Schema{
+				Type: jtd.TypeString,
+				Metadata: map[string]any{
+					"numericString": map[string]any{
+						"type":      "decimal",
+						"min":       json.Number("-12345678901234567.5"),
+						"max":       json.Number("0.1234567890123456789"),
+						"precision": 37,
+						"scale":     20,
+					},
+				},
+			},
+		},
+		{
+			description:    "non numeric string",
+			values:         []string{`"12"`, `"twelve"`, `"13"`},
+			policy:         NumericStringsAnnotate,
+			expectedSchema: Schema{Type: jtd.TypeString},
+		},
+		{
+			description:    "leading zeros",
+			values:         []string{`"007"`},
+			policy:         NumericStringsAnnotate,
+			expectedSchema: Schema{Type: jtd.TypeString},
+		},
+		{
+			description:    "timestamp followed by number",
+			values:         []string{`"2023-01-01T00:00:00Z"`, `"12"`},
+			policy:         NumericStringsAnnotate,
+			expectedSchema: Schema{Type: jtd.TypeString},
+		},
+		{
+			description:    "convert integers",
+			values:         []string{`"12"`, `"-300"`},
+			policy:         NumericStringsConvert,
+			expectedSchema: Schema{Type: jtd.TypeInt16},
+		},
+		{
+			description:    "convert decimals",
+			values:         []string{`"12.50"`},
+			policy:         NumericStringsConvert,
+			expectedSchema: Schema{Type: jtd.TypeFloat64},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			hints := Hints{NumericStrings: tc.policy}
+			gotSchema := InferStrings(tc.values, hints).IntoSchema()
+			assert.EqualValues(t, tc.expectedSchema, gotSchema)
+		})
+	}
+}
+
+func TestInferNumericStringsWithEnumHints(t *testing.T) {
+	hints := Hints{
+		NumericStrings: NumericStringsAnnotate,
+		Enums:          NewHintSet().Add([]string{"code"}),
+	}
+
+	rows := []string{`{"code": "1"}`}
+	expectedSchema := Schema{
+		Properties: map[string]Schema{
+			"code": {Enum: []string{"1"}},
+		},
+	}
+
+	assert.EqualValues(t, expectedSchema, InferStrings(rows, hints).IntoSchema())
+}
@@ -2,7 +2,6 @@ package jtdinfer
 
 import (
 	"reflect"
-	"strconv"
 	"time"
 
 	jtd "github.com/jsontypedef/json-typedef-go"
@@ -26,13 +25,27 @@ const (
 	SchemaTypeValues
 	SchemaTypeDiscriminator
 	SchemaTypeNullable
+	SchemaTypeBytes
 )
 
+// MetadataFormatBytes is the format set in the metadata for strings inferred
+// from binary values such as MessagePack and CBOR byte strings.
+const MetadataFormatBytes = "bytes"
+
+// MetadataPresence is the metadata key where `IntoSchema` writes the fraction,
+// between 0 and 1, of the seen objects where a property was present if
+// `Presence` is set in the hints.
+const MetadataPresence = "presence"
+
 // Properties represents all required and optional properties which is the same as
-// JSON objects.
+// JSON objects. `Seen` is the number of inferred objects and `Present` holds the
+// number of objects each optional property was present in. Required properties
+// are present in all seen objects so they're not counted.
 type Properties struct {
 	Required map[string]*InferredSchema
 	Optional map[string]*InferredSchema
+	Seen     int
+	Present  map[string]int
 }
 
 // Discriminator represents discriminators for the schema.
@@ -42,16 +55,27 @@ type Discriminator struct {
 }
 
 // InferredSchema is the schema while being inferred that holds information
-// about fields.
+// about fields. Inferring never changes an existing `InferredSchema`, unchanged
+// parts are shared with the returned schema so earlier schemas stay valid.
 type InferredSchema struct {
 	SchemaType    SchemaType
 	Number        *InferredNumber
+	NumericString *InferredNumericString
 	Enum          map[string]struct{}
 	Array         *InferredSchema
 	Properties    Properties
 	Values        *InferredSchema
 	Discriminator Discriminator
 	Nullable      *InferredSchema
+	Examples      *Examples
+
+	// generation is the generation of the inference that created the schema.
+	// Schemas created while inferring the current value aren't shared with any
+	// earlier schema and are updated in place to not allocate.
+	generation uint64
+	// sharedEnum is true if the enum is shared with an earlier schema and must
+	// be copied before adding values even if the schema is owned.
+	sharedEnum bool
 }
 
 // NewInferredSchema will return a new, empty, `InferredSchema`.
@@ -65,17 +89,101 @@ func NewInferredSchema() *InferredSchema {
 // Since we don't have enums of this kind in Go we're using a struct with
 // pointers to a schema instead of wrapping the enums.
 func (i *InferredSchema) Infer(value any, hints Hints) *InferredSchema {
-	if value == nil {
+	// Values already decoded as time, e.g. YAML timestamps, are inferred the
+	// same way as their string representation.
+	if t, ok := value.(time.Time); ok {
+		value = t.Format(time.RFC3339Nano)
+	}
+
+	if hints.diagnosing() {
+		hints.diagnoseValue(value)
+	}
+
+	return i.inferValue(value, hints)
+}
+
+// inferValue infers the value without recording diagnostics. This is used to
+// infer the same value again, e.g. for nullable schemas.
+func (i *InferredSchema) inferValue(value any, hints Hints) *InferredSchema {
+	inferred := i.infer(value, hints)
+	if inferred != i && hints.state != nil {
+		inferred.generation = hints.state.generation
+	}
+
+	if !hints.isStopped() {
+		inferred.sampleExamples(i, value, hints)
+	}
+
+	return inferred
+}
+
+// owned returns true if the schema was created while inferring the current
+// value and can be updated in place.
+func (i *InferredSchema) owned(hints Hints) bool {
+	return hints.state != nil && i.generation == hints.state.generation
+}
+
+// reusable returns true if the schema can be returned as is for a value that
+// doesn't change it. Schemas that aren't owned can only be reused if they
+// won't get new examples.
+func (i *InferredSchema) reusable(hints Hints) bool {
+	return hints.Examples.Size <= 0 || i.owned(hints)
+}
+
+func (i *InferredSchema) infer(value any, hints Hints) *InferredSchema {
+	if hints.isStopped() {
+		return i
+	}
+
+	if !hints.visit() {
+		return &InferredSchema{SchemaType: SchemaTypeAny}
+	}
+
+	if i.SchemaType == SchemaTypeNullable {
+		// A nullable schema already accepts null so it's returned as is
+		// instead of being nested for every seen null.
+		if value == nil {
+			return i
+		}
+
+		if i.owned(hints) {
+			i.Nullable = i.Nullable.inferValue(value, hints)
+			return i
+		}
+
 		return &InferredSchema{
 			SchemaType: SchemaTypeNullable,
-			Nullable:   i,
+			Nullable:   i.Nullable.inferValue(value, hints),
 		}
 	}
 
-	if i.SchemaType == SchemaTypeNullable {
+	if value == nil {
+		if !hints.allowNodes(1) {
+			return &InferredSchema{SchemaType: SchemaTypeAny}
+		}
+
 		return &InferredSchema{
 			SchemaType: SchemaTypeNullable,
-			Nullable:   i.Nullable.Infer(value, hints),
+			Nullable:   i,
+		}
+	}
+
+	// Binary values must be checked before slices to not be inferred as an
+	// array of numbers.
+	if _, ok := value.([]byte); ok {
+		switch i.SchemaType {
+		case SchemaTypeBytes:
+			if i.reusable(hints) {
+				return i
+			}
+
+			return &InferredSchema{SchemaType: SchemaTypeBytes}
+		case SchemaTypeUnknown:
+			return &InferredSchema{SchemaType: SchemaTypeBytes}
+		case SchemaTypeString:
+			return &InferredSchema{SchemaType: SchemaTypeString}
+		default:
+			return &InferredSchema{SchemaType: SchemaTypeAny}
 		}
 	}
 
@@ -84,10 +192,7 @@ func (i *InferredSchema) Infer(value any, hints Hints) *InferredSchema {
 	}
 
 	if v, ok := anyAsNumber(value); ok && i.SchemaType == SchemaTypeUnknown {
-		return &InferredSchema{
-			SchemaType: SchemaTypeNumber,
-			Number:     NewNumber().Infer(v),
-		}
+		return newNumberSchema(NewNumber(), v)
 	}
 
 	if v, ok := value.(string); ok && i.SchemaType == SchemaTypeUnknown {
@@ -102,29 +207,35 @@ func (i *InferredSchema) Infer(value any, hints Hints) *InferredSchema {
 			return &InferredSchema{SchemaType: SchemaTypeTimestmap}
 		}
 
-		return &InferredSchema{SchemaType: SchemaTypeString}
-	}
+		schema := &InferredSchema{SchemaType: SchemaTypeString}
+		if hints.NumericStrings != NumericStringsIgnore {
+			schema.NumericString = NewNumericString().Infer(v)
+		}
 
-	if i.SchemaType == SchemaTypeUnknown && reflect.TypeOf(value).Kind() == reflect.Slice {
-		s := reflect.ValueOf(value)
+		return schema
+	}
 
-		subInfer := &InferredSchema{}
-		for i := 0; i < s.Len(); i++ {
-			subInfer = subInfer.Infer(s.Index(i).Interface(), hints.SubHints(strconv.Itoa(i)))
+	if i.SchemaType == SchemaTypeUnknown && isSlice(value) {
+		if !hints.allowNodes(1) {
+			return &InferredSchema{SchemaType: SchemaTypeAny}
 		}
 
 		return &InferredSchema{
 			SchemaType: SchemaTypeArray,
-			Array:      subInfer,
+			Array:      NewInferredSchema().inferElements(value, hints),
 		}
 	}
 
-	if m, ok := value.(map[string]any); ok && i.SchemaType == SchemaTypeUnknown {
+	if o, ok := asObject(value); ok && i.SchemaType == SchemaTypeUnknown {
 		if hints.IsValuesActive() {
+			if !hints.allowNodes(1) {
+				return &InferredSchema{SchemaType: SchemaTypeAny}
+			}
+
 			subInfer := NewInferredSchema()
-			for k, v := range m {
+			o.each(func(k string, v any) {
 				subInfer = subInfer.Infer(v, hints.SubHints(k))
-			}
+			})
 
 			return &InferredSchema{
 				SchemaType: SchemaTypeValues,
@@ -133,39 +244,54 @@ func (i *InferredSchema) Infer(value any, hints Hints) *InferredSchema {
 		}
 
 		if discriminator, ok := hints.PeekActiveDiscriminator(); ok {
-			if mappingKey, ok := m[discriminator].(string); ok {
-				delete(m, discriminator)
+			if mappingKey, ok := o.getString(discriminator); ok {
+				if !hints.allowNodes(1) {
+					return &InferredSchema{SchemaType: SchemaTypeAny}
+				}
 
 				return &InferredSchema{
 					SchemaType: SchemaTypeDiscriminator,
 					Discriminator: Discriminator{
 						Discriminator: discriminator,
 						Mapping: map[string]*InferredSchema{
-							mappingKey: NewInferredSchema().Infer(m, hints),
+							mappingKey: NewInferredSchema().Infer(o.without(discriminator), hints),
 						},
 					},
 				}
 			}
+
+			if hints.diagnosing() {
+				hints.diagnoseTag(o, discriminator)
+			}
+		}
+
+		if !hints.allowProperties(o.len()) || !hints.allowNodes(o.len()) {
+			return &InferredSchema{SchemaType: SchemaTypeAny}
 		}
 
 		properties := make(map[string]*InferredSchema, 0)
-		for k, v := range m {
+		o.each(func(k string, v any) {
 			properties[k] = NewInferredSchema().Infer(v, hints.SubHints(k))
-		}
+		})
 
 		return &InferredSchema{
 			SchemaType: SchemaTypeProperties,
 			Properties: Properties{
 				Required: properties,
+				Seen:     1,
 			},
 		}
 	}
 
 	if i.SchemaType == SchemaTypeAny {
-		return &InferredSchema{SchemaType: SchemaTypeAny}
+		return i
 	}
 
 	if _, ok := value.(bool); ok && i.SchemaType == SchemaTypeBoolean {
+		if i.reusable(hints) {
+			return i
+		}
+
 		return &InferredSchema{SchemaType: SchemaTypeBoolean}
 	}
 
@@ -174,10 +300,12 @@ func (i *InferredSchema) Infer(value any, hints Hints) *InferredSchema {
 	}
 
 	if v, ok := anyAsNumber(value); ok && i.SchemaType == SchemaTypeNumber {
-		return &InferredSchema{
-			SchemaType: SchemaTypeNumber,
-			Number:     i.Number.Infer(v),
+		if i.owned(hints) {
+			i.Number.update(v)
+			return i
 		}
+
+		return newNumberSchema(i.Number, v)
 	}
 
 	if i.SchemaType == SchemaTypeNumber {
@@ -186,6 +314,10 @@ func (i *InferredSchema) Infer(value any, hints Hints) *InferredSchema {
 
 	if v, ok := value.(string); ok && i.SchemaType == SchemaTypeTimestmap {
 		if _, err := time.Parse(time.RFC3339, v); err == nil {
+			if i.reusable(hints) {
+				return i
+			}
+
 			return &InferredSchema{SchemaType: SchemaTypeTimestmap}
 		}
 
@@ -196,38 +328,93 @@ func (i *InferredSchema) Infer(value any, hints Hints) *InferredSchema {
 		return &InferredSchema{SchemaType: SchemaTypeAny}
 	}
 
-	if _, ok := value.(string); ok && i.SchemaType == SchemaTypeString {
-		return &InferredSchema{SchemaType: SchemaTypeString}
+	if v, ok := value.(string); ok && i.SchemaType == SchemaTypeString {
+		if i.NumericString == nil && i.reusable(hints) {
+			return i
+		}
+
+		if i.owned(hints) {
+			i.NumericString = i.NumericString.Infer(v)
+			return i
+		}
+
+		schema := &InferredSchema{SchemaType: SchemaTypeString}
+		if i.NumericString != nil {
+			schema.NumericString = i.NumericString.Infer(v)
+		}
+
+		return schema
 	}
 
 	if i.SchemaType == SchemaTypeString {
 		return &InferredSchema{SchemaType: SchemaTypeAny}
 	}
 
+	if _, ok := value.(string); ok && i.SchemaType == SchemaTypeBytes {
+		return &InferredSchema{SchemaType: SchemaTypeString}
+	}
+
+	if i.SchemaType == SchemaTypeBytes {
+		return &InferredSchema{SchemaType: SchemaTypeAny}
+	}
+
 	if v, ok := value.(string); ok && i.SchemaType == SchemaTypeEnum {
-		i.Enum[v] = struct{}{}
-		return i
+		if _, ok := i.Enum[v]; ok {
+			if i.reusable(hints) {
+				return i
+			}
+
+			return &InferredSchema{
+				SchemaType: SchemaTypeEnum,
+				Enum:       i.Enum,
+				sharedEnum: true,
+			}
+		}
+
+		if !hints.allowEnumValues(len(i.Enum) + 1) {
+			return &InferredSchema{SchemaType: SchemaTypeString}
+		}
+
+		if i.owned(hints) && !i.sharedEnum {
+			i.Enum[v] = struct{}{}
+			return i
+		}
+
+		enum := make(map[string]struct{}, len(i.Enum)+1)
+		for k := range i.Enum {
+			enum[k] = struct{}{}
+		}
+
+		enum[v] = struct{}{}
+
+		if i.owned(hints) {
+			i.Enum, i.sharedEnum = enum, false
+			return i
+		}
+
+		return &InferredSchema{
+			SchemaType: SchemaTypeEnum,
+			Enum:       enum,
+		}
 	}
 
 	if i.SchemaType == SchemaTypeEnum {
 		return &InferredSchema{SchemaType: SchemaTypeAny}
 	}
 
-	if i.SchemaType == SchemaTypeArray && reflect.TypeOf(value).Kind() == reflect.Slice {
-		s := reflect.ValueOf(value)
-
-		subInfer := i.Array
-		for i := 0; i < s.Len(); i++ {
-			subInfer = subInfer.Infer(s.Index(i).Interface(), hints.SubHints(strconv.Itoa(i)))
+	if i.SchemaType == SchemaTypeArray && isSlice(value) {
+		if i.owned(hints) {
+			i.Array = i.Array.inferElements(value, hints)
+			return i
 		}
 
 		return &InferredSchema{
 			SchemaType: SchemaTypeArray,
-			Array:      subInfer,
+			Array:      i.Array.inferElements(value, hints),
 		}
 	}
 
-	if m, ok := value.(map[string]any); ok && i.SchemaType == SchemaTypeProperties {
+	if o, ok := asObject(value); ok && i.SchemaType == SchemaTypeProperties {
 		ensureMap := func(m map[string]*InferredSchema) map[string]*InferredSchema {
 			if m != nil {
 				return m
@@ -236,44 +423,96 @@ func (i *InferredSchema) Infer(value any, hints Hints) *InferredSchema {
 			return make(map[string]*InferredSchema, 0)
 		}
 
-		missingKeys := []string{}
+		newKeys, requiredKeys := 0, 0
+
+		o.each(func(k string, _ any) {
+			_, isRequired := i.Properties.Required[k]
+			_, isOptional := i.Properties.Optional[k]
+
+			if isRequired {
+				requiredKeys++
+			} else if !isOptional {
+				newKeys++
+			}
+		})
+
+		totalKeys := len(i.Properties.Required) + len(i.Properties.Optional) + newKeys
+		if !hints.allowProperties(totalKeys) || !hints.allowNodes(newKeys) {
+			return &InferredSchema{SchemaType: SchemaTypeAny}
+		}
 
-		for k := range i.Properties.Required {
-			if _, ok := m[k]; !ok {
-				missingKeys = append(missingKeys, k)
+		// The properties are copied to not change the receiver if it may be
+		// kept by an earlier inferrer.
+		properties := i.Properties
+		if !i.owned(hints) {
+			properties = Properties{
+				Required: copySchemas(i.Properties.Required),
+				Optional: copySchemas(i.Properties.Optional),
+				Seen:     i.Properties.Seen,
+				Present:  copyCounts(i.Properties.Present),
 			}
 		}
 
-		for _, k := range missingKeys {
-			subInfer := i.Properties.Required[k]
-			delete(i.Properties.Required, k)
+		// Only optional properties are counted so the counts are only
+		// allocated once a property is optional.
+		hasOptional := len(properties.Optional) > 0 || newKeys > 0 || requiredKeys < len(properties.Required)
+		if properties.Present == nil && hasOptional {
+			properties.Present = make(map[string]int)
+		}
+
+		// Required properties are only looked up in the object if any is
+		// missing since looking up keys in tape objects isn't constant time.
+		if requiredKeys < len(properties.Required) {
+			for k, subInfer := range properties.Required {
+				if _, ok := o.get(k); !ok {
+					delete(properties.Required, k)
 
-			i.Properties.Optional = ensureMap(i.Properties.Optional)
-			i.Properties.Optional[k] = subInfer
+					properties.Optional = ensureMap(properties.Optional)
+					properties.Optional[k] = subInfer
+					properties.Present[k] = properties.Seen
+				}
+			}
 		}
 
-		for k, v := range m {
-			if subInfer, ok := i.Properties.Required[k]; ok {
-				i.Properties.Required[k] = subInfer.Infer(v, hints.SubHints(k))
-			} else if subInfer, ok := i.Properties.Optional[k]; ok {
-				i.Properties.Optional[k] = subInfer.Infer(v, hints.SubHints(k))
+		o.each(func(k string, v any) {
+			if subInfer, ok := properties.Required[k]; ok {
+				properties.Required[k] = subInfer.Infer(v, hints.SubHints(k))
+			} else if subInfer, ok := properties.Optional[k]; ok {
+				properties.Optional[k] = subInfer.Infer(v, hints.SubHints(k))
+				properties.Present[k]++
 			} else {
-				i.Properties.Optional = ensureMap(i.Properties.Optional)
-				i.Properties.Optional[k] = NewInferredSchema().Infer(v, hints.SubHints(k))
+				properties.Optional = ensureMap(properties.Optional)
+				properties.Optional[k] = NewInferredSchema().Infer(v, hints.SubHints(k))
+				properties.Present[k] = 1
 			}
+		})
+
+		properties.Seen++
+
+		if i.owned(hints) {
+			i.Properties = properties
+			return i
 		}
 
-		return i
+		return &InferredSchema{
+			SchemaType: SchemaTypeProperties,
+			Properties: properties,
+		}
 	}
 
 	if i.SchemaType == SchemaTypeProperties {
 		return &InferredSchema{SchemaType: SchemaTypeAny}
 	}
 
-	if m, ok := value.(map[string]any); ok && i.SchemaType == SchemaTypeValues {
+	if o, ok := asObject(value); ok && i.SchemaType == SchemaTypeValues {
 		subInfer := i.Values
-		for k, v := range m {
+		o.each(func(k string, v any) {
 			subInfer = subInfer.Infer(v, hints.SubHints(k))
+		})
+
+		if i.owned(hints) {
+			i.Values = subInfer
+			return i
 		}
 
 		return &InferredSchema{
@@ -286,21 +525,45 @@ func (i *InferredSchema) Infer(value any, hints Hints) *InferredSchema {
 		return &InferredSchema{SchemaType: SchemaTypeAny}
 	}
 
-	if m, ok := value.(map[string]any); ok && i.SchemaType == SchemaTypeDiscriminator {
-		mappingKey, ok := m[i.Discriminator.Discriminator].(string)
+	if o, ok := asObject(value); ok && i.SchemaType == SchemaTypeDiscriminator {
+		mappingKey, ok := o.getString(i.Discriminator.Discriminator)
 		if !ok {
+			if hints.diagnosing() {
+				hints.diagnoseTag(o, i.Discriminator.Discriminator)
+			}
+
 			return &InferredSchema{SchemaType: SchemaTypeAny}
 		}
 
-		delete(m, i.Discriminator.Discriminator)
+		subInfer, ok := i.Discriminator.Mapping[mappingKey]
+		if !ok {
+			if !hints.allowNodes(1) {
+				return &InferredSchema{SchemaType: SchemaTypeAny}
+			}
 
-		if _, ok := i.Discriminator.Mapping[mappingKey]; !ok {
-			i.Discriminator.Mapping[mappingKey] = NewInferredSchema()
+			subInfer = NewInferredSchema()
 		}
 
-		i.Discriminator.Mapping[mappingKey] = i.Discriminator.Mapping[mappingKey].Infer(m, hints)
+		// The mapping is copied to not change the receiver if it may be kept
+		// by an earlier inferrer.
+		mapping := i.Discriminator.Mapping
+		if !i.owned(hints) {
+			mapping = copySchemas(mapping)
+		}
 
-		return i
+		mapping[mappingKey] = subInfer.Infer(o.without(i.Discriminator.Discriminator), hints)
+
+		if i.owned(hints) {
+			return i
+		}
+
+		return &InferredSchema{
+			SchemaType: SchemaTypeDiscriminator,
+			Discriminator: Discriminator{
+				Discriminator: i.Discriminator.Discriminator,
+				Mapping:       mapping,
+			},
+		}
 	}
 
 	if i.SchemaType == SchemaTypeDiscriminator {
@@ -310,8 +573,196 @@ func (i *InferredSchema) Infer(value any, hints Hints) *InferredSchema {
 	return &InferredSchema{}
 }
 
-// IntoSchema will convert an `InferredSchema` to a final `Schema`.
+// newNumberSchema returns a new number schema with the number inferred. The
+// schema and the number state are allocated together.
+func newNumberSchema(previous *InferredNumber, n number) *InferredSchema {
+	allocated := &struct {
+		schema InferredSchema
+		number InferredNumber
+	}{
+		number: *previous,
+	}
+
+	allocated.number.update(n)
+	allocated.schema = InferredSchema{
+		SchemaType: SchemaTypeNumber,
+		Number:     &allocated.number,
+	}
+
+	return &allocated.schema
+}
+
+// isSlice returns true if the value is a slice.
+func isSlice(value any) bool {
+	switch value.(type) {
+	case []any, *tapeArray:
+		return true
+	}
+
+	return reflect.TypeOf(value).Kind() == reflect.Slice
+}
+
+// inferElements infers each element of the slice.
+func (i *InferredSchema) inferElements(value any, hints Hints) *InferredSchema {
+	subInfer := i
+
+	if s, ok := value.([]any); ok {
+		for idx, v := range s {
+			subInfer = subInfer.Infer(v, hints.subHintsIndex(idx))
+		}
+
+		return subInfer
+	}
+
+	if a, ok := value.(*tapeArray); ok {
+		a.each(func(idx int, v any) {
+			subInfer = subInfer.Infer(v, hints.subHintsIndex(idx))
+		})
+
+		return subInfer
+	}
+
+	s := reflect.ValueOf(value)
+	for idx := 0; idx < s.Len(); idx++ {
+		subInfer = subInfer.Infer(s.Index(idx).Interface(), hints.subHintsIndex(idx))
+	}
+
+	return subInfer
+}
+
+// copySchemas returns a shallow copy of the schemas.
+func copySchemas(schemas map[string]*InferredSchema) map[string]*InferredSchema {
+	if schemas == nil {
+		return nil
+	}
+
+	copied := make(map[string]*InferredSchema, len(schemas))
+	for k, v := range schemas {
+		copied[k] = v
+	}
+
+	return copied
+}
+
+// presence returns the number of seen objects where the property was present.
+func (p Properties) presence(k string) int {
+	if _, ok := p.Required[k]; ok {
+		return p.Seen
+	}
+
+	return p.Present[k]
+}
+
+// withPresence adds the fraction of the seen objects where the property was
+// present to the schema metadata if presence is enabled.
+func (p Properties) withPresence(k string, schema Schema, hints Hints) Schema {
+	if !hints.Presence || p.Seen == 0 {
+		return schema
+	}
+
+	if schema.Metadata == nil {
+		schema.Metadata = map[string]any{}
+	}
+
+	schema.Metadata[MetadataPresence] = float64(p.presence(k)) / float64(p.Seen)
+
+	return schema
+}
+
+func copyCounts(counts map[string]int) map[string]int {
+	if counts == nil {
+		return nil
+	}
+
+	copied := make(map[string]int, len(counts))
+	for k, v := range counts {
+		copied[k] = v
+	}
+
+	return copied
+}
+
+// object is an object to infer, either a decoded map or an object in a tape.
+type object struct {
+	m    map[string]any
+	tape *tapeObject
+}
+
+// asObject returns the value as an object if it's a map or an object in a
+// tape.
+func asObject(value any) (object, bool) {
+	switch v := value.(type) {
+	case map[string]any:
+		return object{m: v}, true
+	case *tapeObject:
+		return object{tape: v}, true
+	default:
+		return object{}, false
+	}
+}
+
+func (o object) len() int {
+	if o.tape != nil {
+		return o.tape.len
+	}
+
+	return len(o.m)
+}
+
+func (o object) get(key string) (any, bool) {
+	if o.tape != nil {
+		return o.tape.get(key)
+	}
+
+	v, ok := o.m[key]
+
+	return v, ok
+}
+
+// getString returns the value for the key if it's a string.
+func (o object) getString(key string) (string, bool) {
+	v, _ := o.get(key)
+	s, ok := v.(string)
+
+	return s, ok
+}
+
+// each calls fn with each key and value in the object.
+func (o object) each(fn func(key string, value any)) {
+	if o.tape != nil {
+		o.tape.each(fn)
+		return
+	}
+
+	for k, v := range o.m {
+		fn(k, v)
+	}
+}
+
+// without returns the object without the key. Maps are copied to not change
+// the inferred value.
+func (o object) without(key string) any {
+	if o.tape != nil {
+		return o.tape.without(key)
+	}
+
+	without := make(map[string]any, len(o.m))
+	for k, v := range o.m {
+		if k != key {
+			without[k] = v
+		}
+	}
+
+	return without
+}
+
+// IntoSchema will convert an `InferredSchema` to a final `Schema`. If example
+// sampling is enabled in the hints the examples are added to the metadata.
 func (i *InferredSchema) IntoSchema(hints Hints) Schema {
+	return i.withExamples(i.intoSchema(hints), hints)
+}
+
+func (i *InferredSchema) intoSchema(hints Hints) Schema {
 	switch i.SchemaType {
 	case SchemaTypeUnknown, SchemaTypeAny:
 		return Schema{}
@@ -319,13 +770,20 @@ func (i *InferredSchema) IntoSchema(hints Hints) Schema {
 	case SchemaTypeBoolean:
 		return Schema{Type: jtd.TypeBoolean}
 	case SchemaTypeNumber:
-		return Schema{
-			Type: i.Number.IntoType(hints.DefaultNumType),
-		}
+		return i.Number.IntoSchema(hints)
 	case SchemaTypeString:
+		if i.NumericString != nil {
+			return i.NumericString.IntoSchema(hints)
+		}
+
 		return Schema{Type: jtd.TypeString}
 	case SchemaTypeTimestmap:
 		return Schema{Type: jtd.TypeTimestamp}
+	case SchemaTypeBytes:
+		return Schema{
+			Type:     jtd.TypeString,
+			Metadata: map[string]any{"format": MetadataFormatBytes},
+		}
 	case SchemaTypeEnum:
 		enum := make([]string, 0, len(i.Enum))
 		for k := range i.Enum {
@@ -346,7 +804,7 @@ func (i *InferredSchema) IntoSchema(hints Hints) Schema {
 			required = make(map[string]Schema, len(i.Properties.Required))
 
 			for k, v := range i.Properties.Required {
-				required[k] = v.IntoSchema(hints)
+				required[k] = i.Properties.withPresence(k, v.IntoSchema(hints), hints)
 			}
 		}
 
@@ -354,7 +812,7 @@ func (i *InferredSchema) IntoSchema(hints Hints) Schema {
 			optional = make(map[string]Schema, len(i.Properties.Optional))
 
 			for k, v := range i.Properties.Optional {
-				optional[k] = v.IntoSchema(hints)
+				optional[k] = i.Properties.withPresence(k, v.IntoSchema(hints), hints)
 			}
 		}
 
@@ -1,14 +1,26 @@
 package jtdinfer
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
 )
 
+var errTrailingData = errors.New("unexpected data after top-level value")
+
 // Inferrer represents the `InferredSchema` with its state combined with the
-// hints used when inferring.
+// hints used when inferring. Inferring returns a new inferrer and never changes
+// the receiver so an inferrer can be kept as a checkpoint.
 type Inferrer struct {
 	Inference *InferredSchema
 	Hints     Hints
+
+	nodes       int
+	compiled    *compiledHints
+	diagnostics diagnostics
 }
 
 // NewInferrer will create a new inferrer with a default `InferredSchema`.
@@ -16,15 +28,113 @@ func NewInferrer(hints Hints) *Inferrer {
 	return &Inferrer{
 		Inference: NewInferredSchema(),
 		Hints:     hints,
+		compiled:  compileHints(hints),
 	}
 }
 
-// Infer will infer the schema.
+// Infer will infer the schema. If a limit is reached and the `LimitPolicy` is
+// to return an error the inferrer will be returned as is.
 func (i *Inferrer) Infer(value any) *Inferrer {
+	inferrer, err := i.InferContext(context.Background(), value)
+	if err != nil {
+		return i
+	}
+
+	return inferrer
+}
+
+// InferContext will infer the schema and return an error if the context is
+// cancelled or if a limit is reached and the `LimitPolicy` is to return an
+// error. If an error is returned the inferrer will be returned as is.
+func (i *Inferrer) InferContext(ctx context.Context, value any) (*Inferrer, error) {
+	return i.inferContext(ctx, value, nil)
+}
+
+// inferContext infers the value. If the value is read from a tape the
+// duplicate keys and invalid strings in the tape are recorded as diagnostics
+// since they're no longer seen in the value.
+func (i *Inferrer) inferContext(ctx context.Context, value any, t *tape) (*Inferrer, error) {
+	if err := ctx.Err(); err != nil {
+		return i, err
+	}
+
+	// The hint sets are compiled once and reused as long as they're not
+	// changed.
+	compiled := i.compiled
+	if !compiled.matches(i.Hints) {
+		compiled = compileHints(i.Hints)
+	}
+
+	state := newInferState(ctx, i.nodes)
+	hints := i.Hints.withCompiled(compiled)
+	hints.state = state
+
+	if t != nil && hints.diagnosing() {
+		t.diagnose(func(path []string, kind DiagnosticKind) {
+			state.addDiagnostic(formatPointer(path), kind)
+		})
+	}
+
+	inference := i.Inference.Infer(value, hints)
+	if state.err != nil {
+		return i, state.err
+	}
+
 	return &Inferrer{
-		Inference: i.Inference.Infer(value, i.Hints),
-		Hints:     i.Hints,
+		Inference:   inference,
+		Hints:       i.Hints,
+		nodes:       state.nodes,
+		compiled:    compiled,
+		diagnostics: i.diagnostics.merge(state.diagnostics),
+	}, nil
+}
+
+// InferJSON will infer the JSON value in the data, see `InferJSONContext`.
+func (i *Inferrer) InferJSON(data []byte) *Inferrer {
+	inferrer, err := i.InferJSONContext(context.Background(), data)
+	if err != nil {
+		return i
 	}
+
+	return inferrer
+}
+
+// InferJSONContext will infer the JSON value in the data the same way as if it
+// was unmarshalled and passed to `InferContext` but without decoding it into
+// maps and slices first. Numbers are read as `json.Number` and if a key occurs
+// more than once in an object only the last value is used. An error is returned
+// if the data isn't a single valid JSON value.
+func (i *Inferrer) InferJSONContext(ctx context.Context, data []byte) (*Inferrer, error) {
+	t := getTape()
+	defer putTape(t)
+
+	value, err := t.decode(data)
+	if err != nil {
+		return i, fmt.Errorf("jtdinfer: invalid JSON: %w", err)
+	}
+
+	return i.inferContext(ctx, value, t)
+}
+
+// Merge will return a new inferrer with the state of both inferrers as if all
+// values had been inferred by one, see `InferredSchema.Merge`. The hints from
+// the receiver are used. This can be used to infer values in parallel and
+// merge the result.
+func (i *Inferrer) Merge(other *Inferrer) *Inferrer {
+	return &Inferrer{
+		Inference:   i.Inference.Merge(other.Inference, i.Hints),
+		Hints:       i.Hints,
+		nodes:       i.nodes + other.nodes,
+		compiled:    i.compiled,
+		diagnostics: i.diagnostics.merge(other.diagnostics),
+	}
+}
+
+// Diagnostics returns the issues found in the inferred values that makes them
+// ambiguous under JTD, sorted by pointer and kind. Diagnostics are only
+// recorded if `Diagnose` is set in the hints.
+func (i *Inferrer) Diagnostics() []Diagnostic {
+	return i.diagnostics.list()
 }
 
 // IntoSchema will convert the `InferredSchema` into a final `Schema`.
@@ -33,24 +143,56 @@ func (i *Inferrer) IntoSchema() Schema {
 }
 
 // InferStrings accepts a slice of strings and will try to JSON unmarshal each
-// row. If an error occurs the inferrer will return with the state it had when
-// the error occurred. If you already have the type of your data such as a slice
-// of numbers or a map of strings you can pass them directly to `Infer`. This is
-// just a convenience method if all you got is strings.
+// row. Numbers are decoded as `json.Number` to not lose any precision. The
+// rows are read as tokens and inferred without decoding them into maps and
+// slices, see `InferJSONContext`. If an error occurs the inferrer will return
+// with the state it had when the error occurred. If you already have the type
+// of your data such as a slice of numbers or a map of strings you can pass them
+// directly to `Infer`. This is just a convenience method if all you got is
+// strings.
 func InferStrings(rows []string, hints Hints) *Inferrer {
+	inferrer, _ := InferStringsContext(context.Background(), rows, hints)
+	return inferrer
+}
+
+// InferStringsContext works like `InferStrings` but will return an error if a
+// row can't be unmarshalled, the context is cancelled or if a limit is reached.
+// The inferrer will be returned with the state it had before the failing row.
+func InferStringsContext(ctx context.Context, rows []string, hints Hints) (*Inferrer, error) {
 	inferrer := NewInferrer(hints)
-	if len(rows) == 0 {
-		return inferrer
-	}
 
-	for _, row := range rows {
-		var toInfer any
-		if err := json.Unmarshal([]byte(row), &toInfer); err != nil {
-			return inferrer
+	t := getTape()
+	defer putTape(t)
+
+	for idx, row := range rows {
+		toInfer, err := t.decode([]byte(row))
+		if err != nil {
+			return inferrer, fmt.Errorf("jtdinfer: invalid JSON at index %d: %w", idx, err)
 		}
 
-		inferrer = inferrer.Infer(toInfer)
+		inferrer, err = inferrer.inferContext(ctx, toInfer, t)
+		if err != nil {
+			return inferrer, err
+		}
 	}
 
-	return inferrer
+	return inferrer, nil
+}
+
+// unmarshalRow will unmarshal a single JSON value, decoding numbers as
+// `json.Number`.
+func unmarshalRow(row string) (any, error) {
+	decoder := json.NewDecoder(strings.NewReader(row))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, errTrailingData
+	}
+
+	return value, nil
 }
@@ -1,9 +1,11 @@
 package jtdinfer
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -141,6 +143,13 @@ func TestInferString(t *testing.T) {
 				Type: jtd.TypeInt32,
 			},
 		},
+		{
+			description: "integer above float64 precision",
+			values:      []string{"9007199254740993"},
+			expectedSchema: Schema{
+				Type: jtd.TypeFloat64,
+			},
+		},
 		{
 			description: "float without fraction",
 			values:      []string{"1.0"},
@@ -200,6 +209,31 @@ func TestInferString(t *testing.T) {
 	}
 }
 
+func TestInferStringsBigIntAsString(t *testing.T) {
+	rows := []string{
+		`{"id": 9007199254740993, "count": 3}`,
+		`{"id": 18446744073709551615, "count": 4}`,
+	}
+
+	expectedSchema := Schema{
+		Properties: map[string]Schema{
+			"id": {
+				Type:     jtd.TypeString,
+				Metadata: map[string]any{"format": "uint64"},
+			},
+			"count": {Type: jtd.TypeUint8},
+		},
+	}
+
+	inferrer := InferStrings(rows, Hints{BigInt: BigIntAsString})
+	assert.EqualValues(t, expectedSchema, inferrer.IntoSchema())
+	assert.Equal(
+		t,
+		"18446744073709551615",
+		inferrer.Inference.Properties.Required["id"].Number.IntMax.String(),
+	)
+}
+
 func TestInferrerWithEnumHints(t *testing.T) {
 	hints := Hints{
 		Enums: NewHintSet().
@@ -301,6 +335,143 @@ func TestInferWithDiscriminatorHints(t *testing.T) {
 	assert.EqualValues(t, expectedSchema, gotSchema)
 }
 
+func TestInferPresence(t *testing.T) {
+	rows := []string{
+		`{"id": 1, "name": "a", "tags": [], "address": {"city": "x"}}`,
+		`{"id": 2}`,
+		`{"id": 3, "name": "c", "address": {"city": "y", "zip": "1"}}`,
+		`{"id": 4, "name": "d"}`,
+	}
+
+	first := InferStrings(rows[:2], Hints{Presence: true})
+	all := first.InferJSON([]byte(rows[2])).InferJSON([]byte(rows[3]))
+
+	presence := func(schema Schema) any {
+		return schema.Metadata[MetadataPresence]
+	}
+
+	schema := all.IntoSchema()
+	assert.InDelta(t, 1.0, presence(schema.Properties["id"]), 0)
+	assert.InDelta(t, 0.75, presence(schema.OptionalProperties["name"]), 0)
+	assert.InDelta(t, 0.25, presence(schema.OptionalProperties["tags"]), 0)
+	assert.InDelta(t, 0.5, presence(schema.OptionalProperties["address"]), 0)
+
+	// The presence is relative to the objects the property was seen in.
+	address := schema.OptionalProperties["address"]
+	assert.InDelta(t, 1.0, presence(address.Properties["city"]), 0)
+	assert.InDelta(t, 0.5, presence(address.OptionalProperties["zip"]), 0)
+
+	// Earlier inferrers keep their counts.
+	schema = first.IntoSchema()
+	assert.InDelta(t, 0.5, presence(schema.OptionalProperties["name"]), 0)
+
+	// Presence is only added if enabled.
+	schema = InferStrings(rows, WithoutHints()).IntoSchema()
+	assert.Nil(t, schema.Properties["id"].Metadata)
+}
+
+func TestInferrerCheckpoint(t *testing.T) {
+	hints := Hints{
+		Enums:         NewHintSet().Add([]string{"status"}),
+		Discriminator: NewHintSet().Add([]string{"event", "type"}),
+		Examples:      ExampleOptions{Size: 1},
+	}
+
+	rows := []string{
+		`{"id": 1, "status": "ok", "event": {"type": "a", "x": 1}}`,
+		`{"id": 2, "status": "ok", "event": {"type": "a", "x": 2}}`,
+	}
+
+	checkpoint := InferStrings(rows, hints)
+	before := checkpoint.IntoSchema()
+
+	inferrer := checkpoint
+	for _, row := range []string{
+		`{"id": -1, "status": "error", "event": {"type": "b", "y": "z"}}`,
+		`{"status": "unknown", "extra": true, "event": {"type": "a"}}`,
+		`{"id": 3.5, "status": "ok", "event": {"type": "a", "x": null}}`,
+	} {
+		value, err := unmarshalRow(row)
+		require.NoError(t, err)
+
+		inferrer = inferrer.Infer(value)
+	}
+
+	assert.Equal(t, before, checkpoint.IntoSchema())
+	assert.NotEqual(t, before, inferrer.IntoSchema())
+
+	// Inferring from the same checkpoint twice gives the same result.
+	value := map[string]any{"id": 1, "status": "new", "event": map[string]any{"type": "c"}}
+	assert.Equal(t, checkpoint.Infer(value).Inference.clone(), checkpoint.Infer(value).Inference.clone())
+
+	// An enum value already seen followed by a new value in the same inferred
+	// value doesn't add the new value to the checkpoint.
+	elementHints := Hints{
+		Enums:    NewHintSet().Add([]string{"-"}),
+		Examples: ExampleOptions{Size: 2},
+	}
+
+	snapshot := NewInferrer(elementHints).Infer([]any{"a"})
+	snapshot.Infer([]any{"a", "b"})
+
+	assert.Equal(t, []string{"a"}, snapshot.IntoSchema().Elements.Enum)
+}
+
+func TestInferDoesNotChangeValue(t *testing.T) {
+	hints := Hints{Discriminator: NewHintSet().Add([]string{"type"})}
+	value := map[string]any{"type": "a", "x": 1}
+
+	inferrer := NewInferrer(hints).Infer(value).Infer(value)
+
+	assert.Equal(t, map[string]any{"type": "a", "x": 1}, value)
+	assert.Equal(t, "type", inferrer.IntoSchema().Discriminator)
+}
+
+func TestInferAllocs(t *testing.T) {
+	row := map[string]any{
+		"name":    "Joe",
+		"active":  true,
+		"created": "2024-01-01T10:00:00Z",
+		"tags":    []any{"a", "b"},
+	}
+
+	elements := make([]any, 100)
+	for i := range elements {
+		elements[i] = map[string]any{"id": json.Number(strconv.Itoa(i)), "name": "x"}
+	}
+
+	for _, tc := range []struct {
+		description string
+		value       any
+		maxAllocs   float64
+	}{
+		{
+			// The inferrer, the inference state, the object and its properties
+			// are allocated while unchanged leaves are reused.
+			description: "seen object",
+			value:       row,
+			maxAllocs:   8,
+		},
+		{
+			// Elements are updated in place within the same inference so the
+			// allocations doesn't grow with the number of elements.
+			description: "array of objects",
+			value:       elements,
+			maxAllocs:   10,
+		},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			inferrer := NewInferrer(WithoutHints()).Infer(tc.value)
+
+			allocs := testing.AllocsPerRun(100, func() {
+				inferrer.Infer(tc.value)
+			})
+
+			assert.LessOrEqual(t, allocs, tc.maxAllocs)
+		})
+	}
+}
+
 func BenchmarkInferOneRowNoMissingHints(b *testing.B) {
 	rows := generateRows(1)
 	emptyHints := WithoutHints()
@@ -337,3 +508,87 @@ func generateRows(n int) []string {
 
 	return rows
 }
+
+// benchmarkCorpora returns rows resembling real world data: flat events, nested
+// API responses with arrays of objects and tagged events for hints.
+func benchmarkCorpora(n int) map[string][]string {
+	corpora := map[string][]string{}
+	statuses := []string{"active", "inactive", "banned"}
+
+	for i := 0; i < n; i++ {
+		corpora["flat"] = append(corpora["flat"], fmt.Sprintf(
+			`{"id": %d, "name": "user%d", "score": %d.%d, "active": %t, "created": "2024-01-%02dT10:00:00Z"}`,
+			i, i, i%100, i%10, i%2 == 0, i%28+1,
+		))
+
+		email := "null"
+		if i%3 != 0 {
+			email = fmt.Sprintf(`"user%d@example.com"`, i)
+		}
+
+		corpora["nested"] = append(corpora["nested"], fmt.Sprintf(
+			`{"id": %d, "email": %s, "status": %q, "address": {"street": "Street %d", "zip": "%05d"}, `+
+				`"orders": [{"id": %d, "total": %d.5, "items": [{"sku": "a%d", "qty": %d}]}, {"id": %d, "total": 1}], `+
+				`"tags": ["a", "b", "c"]}`,
+			i, email, statuses[i%len(statuses)], i, i, i*10, i, i, i%5, i*10+1,
+		))
+
+		corpora["tagged"] = append(corpora["tagged"], fmt.Sprintf(
+			`{"status": %q, "event": {"type": "type%d", "payload": {"value": %d}}, "labels": {"env": "prod", "team": "t%d"}}`,
+			statuses[i%len(statuses)], i%4, i, i%7,
+		))
+	}
+
+	return corpora
+}
+
+func BenchmarkInferStrings(b *testing.B) {
+	corpora := benchmarkCorpora(1000)
+	hints := map[string]Hints{
+		"tagged": {
+			Enums:         NewHintSet().Add([]string{"status"}),
+			Values:        NewHintSet().Add([]string{"labels"}),
+			Discriminator: NewHintSet().Add([]string{"event", "type"}),
+		},
+	}
+
+	for _, name := range []string{"flat", "nested", "tagged"} {
+		rows := corpora[name]
+
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for n := 0; n < b.N; n++ {
+				InferStrings(rows, hints[name])
+			}
+		})
+	}
+}
+
+func BenchmarkInfer(b *testing.B) {
+	for name, rows := range benchmarkCorpora(1000) {
+		values := make([]any, len(rows))
+		for i, row := range rows {
+			values[i], _ = unmarshalRow(row)
+		}
+
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for n := 0; n < b.N; n++ {
+				inferrer := NewInferrer(WithoutHints())
+				for _, value := range values {
+					inferrer = inferrer.Infer(value)
+				}
+			}
+		})
+	}
+}
+
+func TestInferManyNullsIsNotNested(t *testing.T) {
+	rows := strings.Split(strings.Repeat("null,", 100)+"1", ",")
+	inferrer := InferStrings(rows, WithoutHints())
+
+	assert.Equal(t, SchemaTypeNullable, inferrer.Inference.SchemaType)
+	assert.Equal(t, SchemaTypeNumber, inferrer.Inference.Nullable.SchemaType)
+}
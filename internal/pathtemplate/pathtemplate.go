@@ -0,0 +1,103 @@
+// Package pathtemplate normalizes URL paths to templates by collapsing path
+// segments that are identifiers.
+package pathtemplate
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Normalize converts the path to a template where numeric segments are replaced
+// by `{id}` and UUID segments by `{uuid}`. If the same placeholder occurs more
+// than once it's suffixed with a number, e.g. `/users/{id}/posts/{id2}`.
+func Normalize(path string) string {
+	segments := strings.Split(path, "/")
+	seen := map[string]int{}
+
+	for i, segment := range segments {
+		var name string
+
+		switch {
+		case isNumeric(segment):
+			name = "id"
+		case isUUID(segment):
+			name = "uuid"
+		default:
+			continue
+		}
+
+		seen[name]++
+		if n := seen[name]; n > 1 {
+			name += strconv.Itoa(n)
+		}
+
+		segments[i] = "{" + name + "}"
+	}
+
+	return strings.Join(segments, "/")
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isUUID checks if the string is a UUID in the canonical 8-4-4-4-12 form.
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+
+	for i, r := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if r != '-' {
+				return false
+			}
+		default:
+			if !isHex(r) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func isHex(r rune) bool {
+	return (r >= '0' && r <= '9') || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F')
+}
+
+// Name converts a route such as `GET /users/{id}` to a PascalCase name such as
+// `GetUsersId`.
+func Name(route string) string {
+	var sb strings.Builder
+
+	upper := true
+
+	for _, r := range strings.ToLower(route) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
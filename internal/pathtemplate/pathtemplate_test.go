@@ -0,0 +1,27 @@
+package pathtemplate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	for path, expected := range map[string]string{
+		"":                   "",
+		"/":                  "/",
+		"/users":             "/users",
+		"/users/123":         "/users/{id}",
+		"/users/123/posts/4": "/users/{id}/posts/{id2}",
+		"/v2/users":          "/v2/users",
+		"/orders/0B7D1C4E-8f0a-4c1d-9a7e-6b2f3c4d5e6f/": "/orders/{uuid}/",
+		"/orders/0b7d1c4e8f0a4c1d9a7e6b2f3c4d5e6f":      "/orders/0b7d1c4e8f0a4c1d9a7e6b2f3c4d5e6f",
+	} {
+		assert.Equal(t, expected, Normalize(path), path)
+	}
+}
+
+func TestName(t *testing.T) {
+	assert.Equal(t, "GetUsersId", Name("GET /users/{id}"))
+	assert.Equal(t, "PostV2Orders", Name("POST /v2/orders"))
+}
@@ -0,0 +1,174 @@
+package jtdinfer
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// cancelCheckInterval is the number of visited values between checking if the
+// context has been cancelled.
+const cancelCheckInterval = 1024
+
+// LimitPolicy decides what happens when a limit is reached.
+type LimitPolicy uint8
+
+// Available limit policies.
+const (
+	// LimitPolicyError will stop inferring and return a `*LimitError`. The
+	// inferrer will be left with the state it had before the value was
+	// inferred. This is the default.
+	LimitPolicyError LimitPolicy = iota
+	// LimitPolicyWiden will widen the schema where the limit was reached to
+	// accept any value. Enums are widened to strings.
+	LimitPolicyWiden
+)
+
+// LimitKind represents which limit was reached.
+type LimitKind uint8
+
+// Available limit kinds.
+const (
+	LimitKindDepth LimitKind = iota + 1
+	LimitKindProperties
+	LimitKindEnumValues
+	LimitKindNodes
+)
+
+// String returns the name of the limit.
+func (k LimitKind) String() string {
+	switch k {
+	case LimitKindDepth:
+		return "depth"
+	case LimitKindProperties:
+		return "properties"
+	case LimitKindEnumValues:
+		return "enum values"
+	case LimitKindNodes:
+		return "nodes"
+	}
+
+	return "unknown"
+}
+
+// Limits restricts the resources used when inferring untrusted input. A limit
+// set to zero means no limit. The limits are enforced when inferring with an
+// `Inferrer`.
+type Limits struct {
+	// MaxDepth is the maximum nesting depth of a value where the top-level
+	// value has depth zero.
+	MaxDepth int
+	// MaxProperties is the maximum number of properties for an object.
+	MaxProperties int
+	// MaxEnumValues is the maximum number of distinct values for an enum.
+	MaxEnumValues int
+	// MaxNodes is the maximum number of nodes added to the `InferredSchema`
+	// tree. Nodes are added for each property, element, value, discriminator
+	// mapping and nullable.
+	MaxNodes int
+	// Policy decides what happens when a limit is reached.
+	Policy LimitPolicy
+}
+
+// LimitError is returned when a limit is reached and the `LimitPolicy` is
+// `LimitPolicyError`.
+type LimitError struct {
+	Kind  LimitKind
+	Limit int
+}
+
+// Error implements the error interface.
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("jtdinfer: limit of %d %s reached", e.Limit, e.Kind)
+}
+
+// inferState is the state shared by all values while inferring a single
+// top-level value with an `Inferrer`.
+type inferState struct {
+	nodes      int
+	visits     int
+	err        error
+	ctx        context.Context
+	generation uint64
+
+	diagnostics diagnostics
+}
+
+// generations is incremented for each inferred value to give each inference a
+// unique generation. It's global since schemas can be shared between any
+// inferrers, e.g. by passing the `Inference` of one inferrer to another, and a
+// generation reused by another inferrer would let it change shared schemas.
+var generations atomic.Uint64 //nolint:gochecknoglobals // Must be unique across all inferrers.
+
+func newInferState(ctx context.Context, nodes int) *inferState {
+	return &inferState{
+		nodes:      nodes,
+		ctx:        ctx,
+		generation: generations.Add(1),
+	}
+}
+
+// visit is called for each visited value and returns false if the value
+// shouldn't be inferred, either because the maximum depth is reached or
+// because the context is cancelled.
+func (s *inferState) visit(hints Hints) bool {
+	s.visits++
+	if s.visits%cancelCheckInterval == 0 {
+		if err := s.ctx.Err(); err != nil {
+			s.err = err
+			return false
+		}
+	}
+
+	return s.allow(hints.Limits, LimitKindDepth, hints.Limits.MaxDepth, hints.depth)
+}
+
+// allow checks if the value is within the limit. If it's not and the policy is
+// to return an error the error will be set.
+func (s *inferState) allow(limits Limits, kind LimitKind, limit, value int) bool {
+	if limit <= 0 || value <= limit {
+		return true
+	}
+
+	if limits.Policy == LimitPolicyError && s.err == nil {
+		s.err = &LimitError{Kind: kind, Limit: limit}
+	}
+
+	return false
+}
+
+// isStopped returns true if an error has occurred and inferring should stop.
+func (h Hints) isStopped() bool {
+	return h.state != nil && h.state.err != nil
+}
+
+// visit checks the limits for visiting a value, see `inferState.visit`.
+func (h Hints) visit() bool {
+	return h.state == nil || h.state.visit(h)
+}
+
+// allowNodes checks if n more nodes can be added to the tree and if so counts
+// them.
+func (h Hints) allowNodes(n int) bool {
+	if h.state == nil {
+		return true
+	}
+
+	if !h.state.allow(h.Limits, LimitKindNodes, h.Limits.MaxNodes, h.state.nodes+n) {
+		return false
+	}
+
+	h.state.nodes += n
+
+	return true
+}
+
+// allowProperties checks if an object can have n properties.
+func (h Hints) allowProperties(n int) bool {
+	return h.state == nil || h.state.allow(h.Limits, LimitKindProperties, h.Limits.MaxProperties, n)
+}
+
+// allowEnumValues checks if an enum can have n values.
+func (h Hints) allowEnumValues(n int) bool {
+	return h.state == nil || h.state.allow(h.Limits, LimitKindEnumValues, h.Limits.MaxEnumValues, n)
+}
@@ -0,0 +1,145 @@
+package jtdinfer
+
+import (
+	"context"
+	"testing"
+
+	jtd "github.com/jsontypedef/json-typedef-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimits(t *testing.T) {
+	cases := []struct {
+		description    string
+		rows           []string
+		limits         Limits
+		enums          HintSet
+		expectedKind   LimitKind
+		expectedSchema Schema
+	}{
+		{
+			description:  "depth",
+			rows:         []string{`{"a": {"b": 1}, "c": 1}`},
+			limits:       Limits{MaxDepth: 1},
+			expectedKind: LimitKindDepth,
+			expectedSchema: Schema{
+				Properties: map[string]Schema{
+					"a": {Properties: map[string]Schema{"b": {}}},
+					"c": {Type: jtd.TypeUint8},
+				},
+			},
+		},
+		{
+			description:  "properties",
+			rows:         []string{`{"a": {"b": 1, "c": 2}}`},
+			limits:       Limits{MaxProperties: 1},
+			expectedKind: LimitKindProperties,
+			expectedSchema: Schema{
+				Properties: map[string]Schema{"a": {}},
+			},
+		},
+		{
+			description:    "properties over multiple rows",
+			rows:           []string{`{"a": 1}`, `{"b": 1}`},
+			limits:         Limits{MaxProperties: 1},
+			expectedKind:   LimitKindProperties,
+			expectedSchema: Schema{},
+		},
+		{
+			description:  "enum values",
+			rows:         []string{`"a"`, `"a"`, `"b"`},
+			limits:       Limits{MaxEnumValues: 1},
+			enums:        NewHintSet().Add([]string{}),
+			expectedKind: LimitKindEnumValues,
+			expectedSchema: Schema{
+				Type: jtd.TypeString,
+			},
+		},
+		{
+			description:  "nodes",
+			rows:         []string{`{"a": [[1]]}`},
+			limits:       Limits{MaxNodes: 2},
+			expectedKind: LimitKindNodes,
+			expectedSchema: Schema{
+				Properties: map[string]Schema{
+					"a": {Elements: &Schema{}},
+				},
+			},
+		},
+		{
+			// Only the first null is counted so the array is added but not its
+			// element.
+			description:  "nullable nodes",
+			rows:         []string{`{"a": null}`, `{"a": null}`, `{"a": [null]}`},
+			limits:       Limits{MaxNodes: 3},
+			expectedKind: LimitKindNodes,
+			expectedSchema: Schema{
+				Properties: map[string]Schema{
+					"a": {Elements: &Schema{}, Nullable: true},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			hints := Hints{Limits: tc.limits, Enums: tc.enums}
+
+			inferrer, err := InferStringsContext(context.Background(), tc.rows, hints)
+
+			var limitErr *LimitError
+			require.ErrorAs(t, err, &limitErr)
+			assert.Equal(t, tc.expectedKind, limitErr.Kind)
+
+			// The failing row should not be part of the inferrer.
+			expectedInferrer := InferStrings(tc.rows[:len(tc.rows)-1], Hints{Enums: tc.enums})
+			assert.Equal(t, expectedInferrer.IntoSchema(), inferrer.IntoSchema())
+
+			hints.Limits.Policy = LimitPolicyWiden
+
+			inferrer, err = InferStringsContext(context.Background(), tc.rows, hints)
+			require.NoError(t, err)
+			assert.EqualValues(t, tc.expectedSchema, inferrer.IntoSchema())
+		})
+	}
+}
+
+func TestLimitsWithinLimits(t *testing.T) {
+	hints := Hints{
+		Limits: Limits{
+			MaxDepth:      2,
+			MaxProperties: 2,
+			MaxEnumValues: 2,
+			MaxNodes:      5,
+		},
+		Enums: NewHintSet().Add([]string{"b"}),
+	}
+
+	rows := []string{
+		`{"a": [1, 2, 3], "b": "x"}`,
+		`{"a": [4], "b": "y"}`,
+		`{"a": null, "b": "x"}`,
+	}
+
+	_, err := InferStringsContext(context.Background(), rows, hints)
+	require.NoError(t, err)
+}
+
+func TestInferContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	inferrer := NewInferrer(WithoutHints())
+
+	gotInferrer, err := inferrer.InferContext(ctx, "string")
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Same(t, inferrer, gotInferrer)
+
+	// Cancellation is also detected while inferring a value.
+	hints := WithoutHints()
+	hints.state = newInferState(ctx, 0)
+
+	NewInferredSchema().Infer(make([]any, cancelCheckInterval*2), hints)
+	require.ErrorIs(t, hints.state.err, context.Canceled)
+}
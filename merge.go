@@ -0,0 +1,378 @@
+package jtdinfer
+
+// Merge returns a new `InferredSchema` holding the state of both schemas as if
+// all values inferred by both had been inferred by one. Neither schema is
+// modified and the returned schema doesn't share any state with them. Enums
+// that together exceed `MaxEnumValues` in the hints are widened to strings.
+// Properties that are required in both schemas are required and all others are
+// optional.
+func (i *InferredSchema) Merge(other *InferredSchema, hints Hints) *InferredSchema {
+	merged := i.merge(other, hints)
+	merged.Examples = i.Examples.merge(other.Examples, hints.Examples.Size)
+
+	return merged
+}
+
+func (i *InferredSchema) merge(other *InferredSchema, hints Hints) *InferredSchema {
+	if other.SchemaType == SchemaTypeUnknown {
+		return i.cloneWithoutExamples()
+	}
+
+	if i.SchemaType == SchemaTypeUnknown {
+		return other.cloneWithoutExamples()
+	}
+
+	if i.SchemaType == SchemaTypeNullable || other.SchemaType == SchemaTypeNullable {
+		return &InferredSchema{
+			SchemaType: SchemaTypeNullable,
+			Nullable:   i.nonNullable().Merge(other.nonNullable(), hints),
+		}
+	}
+
+	if i.SchemaType == SchemaTypeAny || other.SchemaType == SchemaTypeAny {
+		return &InferredSchema{SchemaType: SchemaTypeAny}
+	}
+
+	switch {
+	case i.SchemaType == SchemaTypeTimestmap && other.SchemaType == SchemaTypeString,
+		i.SchemaType == SchemaTypeString && other.SchemaType == SchemaTypeTimestmap,
+		i.SchemaType == SchemaTypeBytes && other.SchemaType == SchemaTypeString,
+		i.SchemaType == SchemaTypeString && other.SchemaType == SchemaTypeBytes,
+		i.SchemaType == SchemaTypeEnum && other.SchemaType == SchemaTypeString,
+		i.SchemaType == SchemaTypeString && other.SchemaType == SchemaTypeEnum:
+		// Timestamps, bytes and enum values aren't numeric strings.
+		return &InferredSchema{SchemaType: SchemaTypeString}
+	case i.SchemaType != other.SchemaType:
+		return &InferredSchema{SchemaType: SchemaTypeAny}
+	}
+
+	switch i.SchemaType {
+	case SchemaTypeBoolean, SchemaTypeTimestmap, SchemaTypeBytes:
+		return &InferredSchema{SchemaType: i.SchemaType}
+	case SchemaTypeNumber:
+		return &InferredSchema{
+			SchemaType: SchemaTypeNumber,
+			Number:     i.Number.merge(other.Number),
+		}
+	case SchemaTypeString:
+		return &InferredSchema{
+			SchemaType:    SchemaTypeString,
+			NumericString: i.NumericString.merge(other.NumericString),
+		}
+	case SchemaTypeEnum:
+		enum := make(map[string]struct{}, len(i.Enum)+len(other.Enum))
+		for k := range i.Enum {
+			enum[k] = struct{}{}
+		}
+
+		for k := range other.Enum {
+			enum[k] = struct{}{}
+		}
+
+		if limit := hints.Limits.MaxEnumValues; limit > 0 && len(enum) > limit {
+			return &InferredSchema{SchemaType: SchemaTypeString}
+		}
+
+		return &InferredSchema{
+			SchemaType: SchemaTypeEnum,
+			Enum:       enum,
+		}
+	case SchemaTypeArray:
+		return &InferredSchema{
+			SchemaType: SchemaTypeArray,
+			Array:      i.Array.Merge(other.Array, hints),
+		}
+	case SchemaTypeProperties:
+		return &InferredSchema{
+			SchemaType: SchemaTypeProperties,
+			Properties: i.Properties.merge(other.Properties, hints),
+		}
+	case SchemaTypeValues:
+		return &InferredSchema{
+			SchemaType: SchemaTypeValues,
+			Values:     i.Values.Merge(other.Values, hints),
+		}
+	case SchemaTypeDiscriminator:
+		if i.Discriminator.Discriminator != other.Discriminator.Discriminator {
+			return &InferredSchema{SchemaType: SchemaTypeAny}
+		}
+
+		return &InferredSchema{
+			SchemaType: SchemaTypeDiscriminator,
+			Discriminator: Discriminator{
+				Discriminator: i.Discriminator.Discriminator,
+				Mapping:       mergeSchemas(i.Discriminator.Mapping, other.Discriminator.Mapping, hints),
+			},
+		}
+	case SchemaTypeUnknown, SchemaTypeAny, SchemaTypeNullable:
+	}
+
+	return &InferredSchema{SchemaType: SchemaTypeAny}
+}
+
+// merge merges the properties where only properties required in both are
+// required. The presence of optional properties is the sum of the presence in
+// both.
+func (p Properties) merge(other Properties, hints Hints) Properties {
+	var (
+		required = map[string]*InferredSchema{}
+		optional = map[string]*InferredSchema{}
+		present  = map[string]int{}
+	)
+
+	lookup := func(props Properties, k string) (*InferredSchema, bool, bool) {
+		if v, ok := props.Required[k]; ok {
+			return v, true, true
+		}
+
+		v, ok := props.Optional[k]
+
+		return v, ok, false
+	}
+
+	add := func(k string) {
+		if _, ok := required[k]; ok {
+			return
+		}
+
+		if _, ok := optional[k]; ok {
+			return
+		}
+
+		a, inA, requiredA := lookup(p, k)
+		b, inB, requiredB := lookup(other, k)
+
+		var merged *InferredSchema
+
+		switch {
+		case inA && inB:
+			merged = a.Merge(b, hints)
+		case inA:
+			merged = a.clone()
+		default:
+			merged = b.clone()
+		}
+
+		if requiredA && requiredB {
+			required[k] = merged
+		} else {
+			optional[k] = merged
+			present[k] = p.presence(k) + other.presence(k)
+		}
+	}
+
+	for _, props := range []Properties{p, other} {
+		for k := range props.Required {
+			add(k)
+		}
+
+		for k := range props.Optional {
+			add(k)
+		}
+	}
+
+	if len(optional) == 0 {
+		optional, present = nil, nil
+	}
+
+	return Properties{
+		Required: required,
+		Optional: optional,
+		Seen:     p.Seen + other.Seen,
+		Present:  present,
+	}
+}
+
+// mergeSchemas merges the schemas for keys in both maps and clones the schemas
+// for keys only in one of them.
+func mergeSchemas(a, b map[string]*InferredSchema, hints Hints) map[string]*InferredSchema {
+	merged := make(map[string]*InferredSchema, max(len(a), len(b)))
+
+	for k, v := range a {
+		if other, ok := b[k]; ok {
+			merged[k] = v.Merge(other, hints)
+		} else {
+			merged[k] = v.clone()
+		}
+	}
+
+	for k, v := range b {
+		if _, ok := a[k]; !ok {
+			merged[k] = v.clone()
+		}
+	}
+
+	return merged
+}
+
+// nonNullable returns the schema wrapped by a nullable schema or the schema
+// itself if it's not nullable.
+func (i *InferredSchema) nonNullable() *InferredSchema {
+	if i.SchemaType == SchemaTypeNullable {
+		return i.Nullable
+	}
+
+	return i
+}
+
+// cloneWithoutExamples returns a deep copy of the schema without the
+// examples, which are merged separately.
+func (i *InferredSchema) cloneWithoutExamples() *InferredSchema {
+	cloned := i.clone()
+	cloned.Examples = nil
+
+	return cloned
+}
+
+// clone returns a deep copy of the schema.
+func (i *InferredSchema) clone() *InferredSchema {
+	cloned := *i
+	cloned.Examples = i.Examples.clone()
+	cloned.generation = 0
+	cloned.sharedEnum = false
+
+	if i.Enum != nil {
+		cloned.Enum = make(map[string]struct{}, len(i.Enum))
+		for k := range i.Enum {
+			cloned.Enum[k] = struct{}{}
+		}
+	}
+
+	if i.Array != nil {
+		cloned.Array = i.Array.clone()
+	}
+
+	if i.Values != nil {
+		cloned.Values = i.Values.clone()
+	}
+
+	if i.Nullable != nil {
+		cloned.Nullable = i.Nullable.clone()
+	}
+
+	cloned.Properties = Properties{
+		Required: cloneSchemas(i.Properties.Required),
+		Optional: cloneSchemas(i.Properties.Optional),
+		Seen:     i.Properties.Seen,
+		Present:  copyCounts(i.Properties.Present),
+	}
+	cloned.Discriminator.Mapping = cloneSchemas(i.Discriminator.Mapping)
+
+	return &cloned
+}
+
+func cloneSchemas(schemas map[string]*InferredSchema) map[string]*InferredSchema {
+	if schemas == nil {
+		return nil
+	}
+
+	cloned := make(map[string]*InferredSchema, len(schemas))
+	for k, v := range schemas {
+		cloned[k] = v.clone()
+	}
+
+	return cloned
+}
+
+// merge returns the number state for all numbers seen by both.
+func (i *InferredNumber) merge(other *InferredNumber) *InferredNumber {
+	merged := *i
+
+	switch {
+	case other.Count == 0:
+	case i.Count == 0:
+		merged.Min, merged.Max = other.Min, other.Max
+	default:
+		merged.Min = min(i.Min, other.Min)
+		merged.Max = max(i.Max, other.Max)
+	}
+
+	merged.Count += other.Count
+	merged.Sum += other.Sum
+	merged.NonFinite += other.NonFinite
+	merged.IsInteger = i.IsInteger && other.IsInteger
+	merged.IsFloat32 = i.IsFloat32 && other.IsFloat32
+	merged.IntMin, merged.IntMax = nil, nil
+
+	// The integers are never modified after being set so they can be shared.
+	if merged.IsInteger {
+		merged.IntMin = minInt(i.IntMin, other.IntMin)
+		merged.IntMax = maxInt(i.IntMax, other.IntMax)
+	}
+
+	return &merged
+}
+
+// merge returns the numeric string state for both or nil if any of them isn't
+// numeric.
+func (i *InferredNumericString) merge(other *InferredNumericString) *InferredNumericString {
+	if i == nil || other == nil {
+		return nil
+	}
+
+	return &InferredNumericString{
+		Number:        i.Number.merge(other.Number),
+		IntegerDigits: max(i.IntegerDigits, other.IntegerDigits),
+		Scale:         max(i.Scale, other.Scale),
+		Min:           minRat(i.Min, other.Min),
+		Max:           maxRat(i.Max, other.Max),
+	}
+}
+
+// merge returns a sample of both reservoirs where each kept value is picked
+// from either reservoir with the probability of the number of values it has
+// seen, keeping the sample uniform.
+func (e *Examples) merge(other *Examples, size int) *Examples {
+	switch {
+	case e == nil:
+		return other.clone()
+	case other == nil:
+		return e.clone()
+	}
+
+	merged := &Examples{
+		Seen: e.Seen + other.Seen,
+		rng:  e.rng ^ other.rng,
+	}
+
+	var (
+		a, b   = append([]any{}, e.Values...), append([]any{}, other.Values...)
+		wa, wb = uint64(e.Seen), uint64(other.Seen)
+	)
+
+	take := func(values []any) ([]any, any) {
+		j := merged.next() % uint64(len(values))
+		value := values[j]
+		values[j] = values[len(values)-1]
+
+		return values[:len(values)-1], value
+	}
+
+	for len(merged.Values) < size && (len(a) > 0 || len(b) > 0) {
+		var value any
+
+		if len(b) == 0 || (len(a) > 0 && merged.next()%(wa+wb) < wa) {
+			a, value = take(a)
+			wa--
+		} else {
+			b, value = take(b)
+			wb--
+		}
+
+		merged.Values = append(merged.Values, value)
+	}
+
+	return merged
+}
+
+// clone returns a copy of the examples.
+func (e *Examples) clone() *Examples {
+	if e == nil {
+		return nil
+	}
+
+	cloned := *e
+	cloned.Values = append([]any{}, e.Values...)
+
+	return &cloned
+}
@@ -0,0 +1,142 @@
+package jtdinfer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	cases := []struct {
+		description string
+		hints       Hints
+		first       []string
+		second      []string
+	}{
+		{
+			description: "numbers",
+			first:       []string{"1", "200"},
+			second:      []string{"-3", "2.5"},
+		},
+		{
+			description: "big integers",
+			first:       []string{"1"},
+			second:      []string{"18446744073709551615"},
+		},
+		{
+			description: "nullable",
+			first:       []string{"null"},
+			second:      []string{`"a"`},
+		},
+		{
+			description: "timestamp and string",
+			first:       []string{`"2020-01-01T00:00:00Z"`},
+			second:      []string{`"a"`},
+		},
+		{
+			description: "mixed types",
+			first:       []string{"true"},
+			second:      []string{"1"},
+		},
+		{
+			description: "optional properties",
+			first:       []string{`{"a": 1, "b": "x"}`, `{"a": 2}`},
+			second:      []string{`{"a": 3, "c": [1, 2]}`, `{"a": null, "c": []}`},
+		},
+		{
+			description: "only in one",
+			first:       []string{`{"a": 1}`},
+			second:      []string{},
+		},
+		{
+			description: "enums",
+			hints:       Hints{Enums: NewHintSet().Add([]string{"status"})},
+			first:       []string{`{"status": "ok"}`},
+			second:      []string{`{"status": "error"}`, `{"status": "ok"}`},
+		},
+		{
+			description: "values",
+			hints:       Hints{Values: NewHintSet().Add([]string{})},
+			first:       []string{`{"a": 1}`},
+			second:      []string{`{"b": 1.5}`},
+		},
+		{
+			description: "discriminator",
+			hints:       Hints{Discriminator: NewHintSet().Add([]string{"type"})},
+			first:       []string{`{"type": "a", "x": 1}`},
+			second:      []string{`{"type": "b", "y": "z"}`, `{"type": "a"}`},
+		},
+		{
+			description: "presence",
+			hints:       Hints{Presence: true},
+			first:       []string{`{"a": 1, "b": 1}`, `{"a": 1}`},
+			second:      []string{`{"a": 1, "c": 1}`, `{"b": 2}`},
+		},
+		{
+			description: "numeric strings",
+			hints:       Hints{NumericStrings: NumericStringsAnnotate},
+			first:       []string{`"1.25"`},
+			second:      []string{`"-300"`},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			all := InferStrings(append(append([]string{}, tc.first...), tc.second...), tc.hints)
+			first := InferStrings(tc.first, tc.hints)
+			second := InferStrings(tc.second, tc.hints)
+
+			// The inferred state is compared since the order of enum values
+			// in the schema isn't stable. It's cloned to not compare the
+			// generation it was inferred in.
+			assert.Equal(t, all.Inference.clone(), first.Merge(second).Inference)
+			assert.Equal(t, all.Inference.clone(), second.Merge(first).Inference)
+		})
+	}
+}
+
+func TestMergeEnumLimit(t *testing.T) {
+	hints := Hints{
+		Enums:  NewHintSet().Add([]string{}),
+		Limits: Limits{MaxEnumValues: 2, Policy: LimitPolicyWiden},
+	}
+
+	first := InferStrings([]string{`"a"`, `"b"`}, hints)
+	second := InferStrings([]string{`"c"`}, hints)
+
+	assert.Equal(t, Schema{Type: "string"}, first.Merge(second).IntoSchema())
+}
+
+func TestMergeDoesNotShareState(t *testing.T) {
+	hints := Hints{Enums: NewHintSet().Add([]string{"status"})}
+
+	first := InferStrings([]string{`{"status": "ok"}`}, hints)
+	second := InferStrings([]string{`{"status": "error"}`}, hints)
+	before := first.IntoSchema()
+
+	merged := first.Merge(second)
+	merged = merged.Infer(map[string]any{"status": "new", "extra": true})
+
+	assert.Equal(t, before, first.IntoSchema())
+	assert.Len(t, merged.IntoSchema().Properties["status"].Enum, 3)
+}
+
+func TestMergeExamples(t *testing.T) {
+	hints := Hints{Examples: ExampleOptions{Size: 3}}
+
+	first := InferStrings([]string{`"a"`, `"b"`}, hints)
+	second := InferStrings([]string{`"c"`, `"d"`, `"e"`, `"f"`}, hints)
+
+	merged := first.Merge(second)
+	require.NotNil(t, merged.Inference.Examples)
+	assert.Equal(t, 6, merged.Inference.Examples.Seen)
+	assert.Len(t, merged.Inference.Examples.Values, 3)
+
+	for _, v := range merged.Inference.Examples.Values {
+		assert.Contains(t, []any{"a", "b", "c", "d", "e", "f"}, v)
+	}
+
+	small := first.Merge(InferStrings([]string{`"c"`}, hints))
+	assert.ElementsMatch(t, []any{"a", "b", "c"}, small.Inference.Examples.Values)
+}
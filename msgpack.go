@@ -0,0 +1,229 @@
+package jtdinfer
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// msgpackTimestamp is the extension type for timestamps.
+const msgpackTimestamp = -1
+
+// InferMessagePack will decode all values in the MessagePack stream and infer
+// each value as a separate row. See `InferMessagePackContext` for details.
+func InferMessagePack(r io.Reader, hints Hints) (*Inferrer, error) {
+	return InferMessagePackContext(context.Background(), r, hints)
+}
+
+// InferMessagePackContext will decode all values in the MessagePack stream and
+// infer each value as a separate row. Binary values are inferred as strings
+// with the format `bytes` in the metadata, timestamp extensions as timestamps
+// and integer map keys are converted to strings. Other extensions are inferred
+// as binary values.
+func InferMessagePackContext(ctx context.Context, r io.Reader, hints Hints) (*Inferrer, error) {
+	d := &msgpackDecoder{newBinaryReader(r)}
+	return inferBinaryStream(ctx, d.binaryReader, "MessagePack", hints, d.decode)
+}
+
+type msgpackDecoder struct {
+	*binaryReader
+}
+
+// decode decodes the next value.
+func (d *msgpackDecoder) decode() (any, error) {
+	c, err := d.readByte()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c >= 0x80 && c <= 0x8f:
+		return d.decodeMap(uint64(c & 0x0f))
+	case c >= 0x90 && c <= 0x9f:
+		return d.decodeArray(uint64(c & 0x0f))
+	case c >= 0xa0 && c <= 0xbf:
+		return d.decodeString(uint64(c & 0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.readUint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+
+		return d.readN(n)
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.readUint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+
+		return d.decodeExt(n)
+	case 0xca:
+		v, err := d.readUint(4)
+		if err != nil {
+			return nil, err
+		}
+
+		return float64(math.Float32frombits(uint32(v))), nil
+	case 0xcb:
+		v, err := d.readUint(8)
+		if err != nil {
+			return nil, err
+		}
+
+		return math.Float64frombits(v), nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		v, err := d.readUint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+
+		return v, nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+
+		v, err := d.readUint(size)
+		if err != nil {
+			return nil, err
+		}
+
+		// Sign extend from the size of the integer.
+		shift := 64 - 8*size
+
+		return int64(v<<shift) >> shift, nil
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.decodeExt(1 << (c - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.readUint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+
+		return d.decodeString(n)
+	case 0xdc, 0xdd:
+		n, err := d.readUint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+
+		return d.decodeArray(n)
+	case 0xde, 0xdf:
+		n, err := d.readUint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+
+		return d.decodeMap(n)
+	}
+
+	return nil, fmt.Errorf("%w: type 0x%02x", errBinaryInvalid, c)
+}
+
+func (d *msgpackDecoder) decodeString(n uint64) (any, error) {
+	b, err := d.readN(n)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+func (d *msgpackDecoder) decodeArray(n uint64) (any, error) {
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+
+	defer d.leave()
+
+	values := make([]any, 0, min(n, binaryPreallocate))
+
+	for i := uint64(0); i < n; i++ {
+		v, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, v)
+	}
+
+	return values, nil
+}
+
+func (d *msgpackDecoder) decodeMap(n uint64) (any, error) {
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+
+	defer d.leave()
+
+	m := make(map[string]any, min(n, binaryPreallocate))
+
+	for i := uint64(0); i < n; i++ {
+		k, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := mapKey(k)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+
+		m[key] = v
+	}
+
+	return m, nil
+}
+
+// decodeExt decodes an extension with a data length of n. Timestamps are
+// decoded as `time.Time` and all other extensions as their data.
+func (d *msgpackDecoder) decodeExt(n uint64) (any, error) {
+	t, err := d.readByte()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	data, err := d.readN(n)
+	if err != nil {
+		return nil, err
+	}
+
+	if int8(t) != msgpackTimestamp {
+		return data, nil
+	}
+
+	switch len(data) {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(data)), 0).UTC(), nil
+	case 8:
+		v := binary.BigEndian.Uint64(data)
+		return time.Unix(int64(v&0x3_ffff_ffff), int64(v>>34)).UTC(), nil
+	case 12:
+		nsec := binary.BigEndian.Uint32(data[:4])
+		sec := binary.BigEndian.Uint64(data[4:])
+
+		return time.Unix(int64(sec), int64(nsec)).UTC(), nil
+	}
+
+	return nil, fmt.Errorf("%w: timestamp with %d bytes", errBinaryInvalid, len(data))
+}
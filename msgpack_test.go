@@ -0,0 +1,86 @@
+package jtdinfer
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	jtd "github.com/jsontypedef/json-typedef-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInferMessagePack(t *testing.T) {
+	stream := []byte{
+		// {"id": 1, "data": bin(0x01 0x02), "at": timestamp(1), 1: "one", "n": -5}
+		0x85,
+		0xa2, 'i', 'd', 0x01,
+		0xa4, 'd', 'a', 't', 'a', 0xc4, 0x02, 0x01, 0x02,
+		0xa2, 'a', 't', 0xd6, 0xff, 0x00, 0x00, 0x00, 0x01,
+		0x01, 0xa3, 'o', 'n', 'e',
+		0xa1, 'n', 0xfb,
+		// {"id": 256, "data": bin(), "at": timestamp(1, 5ns), 1: nil, "n": 1.5}
+		0x85,
+		0xa2, 'i', 'd', 0xcd, 0x01, 0x00,
+		0xa4, 'd', 'a', 't', 'a', 0xc4, 0x00,
+		0xa2, 'a', 't', 0xd7, 0xff, 0x00, 0x00, 0x00, 0x14, 0x00, 0x00, 0x00, 0x01,
+		0x01, 0xc0,
+		0xa1, 'n', 0xcb, 0x3f, 0xf8, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+
+	inferrer, err := InferMessagePack(bytes.NewReader(stream), WithoutHints())
+	require.NoError(t, err)
+
+	assert.Equal(t, Schema{
+		Properties: map[string]Schema{
+			"id": {Type: jtd.TypeUint16},
+			"data": {
+				Type:     jtd.TypeString,
+				Metadata: map[string]any{"format": MetadataFormatBytes},
+			},
+			"at": {Type: jtd.TypeTimestamp},
+			"1":  {Type: jtd.TypeString, Nullable: true},
+			"n":  {Type: jtd.TypeFloat64},
+		},
+	}, inferrer.IntoSchema())
+}
+
+func TestInferMessagePackErrors(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		stream      []byte
+		err         error
+		message     string
+	}{
+		{
+			description: "truncated",
+			stream:      []byte{0x01, 0x92, 0x01},
+			err:         io.ErrUnexpectedEOF,
+			message:     "jtdinfer: invalid MessagePack at offset 1: unexpected EOF",
+		},
+		{
+			description: "invalid type",
+			stream:      []byte{0xc1},
+			err:         errBinaryInvalid,
+			message:     "jtdinfer: invalid MessagePack at offset 0: invalid value: type 0xc1",
+		},
+		{
+			description: "invalid key",
+			stream:      []byte{0x81, 0xc3, 0x01},
+			err:         errBinaryMapKey,
+			message:     "jtdinfer: invalid MessagePack at offset 0: map key must be a string or an integer, got bool",
+		},
+		{
+			description: "huge length",
+			stream:      []byte{0xdb, 0xff, 0xff, 0xff, 0xff, 'a'},
+			err:         io.ErrUnexpectedEOF,
+			message:     "jtdinfer: invalid MessagePack at offset 0: unexpected EOF",
+		},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			_, err := InferMessagePack(bytes.NewReader(tc.stream), WithoutHints())
+			require.ErrorIs(t, err, tc.err)
+			assert.EqualError(t, err, tc.message)
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package jtdinfer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrPointerNotFound is returned when the JSON Pointer doesn't point to a
+	// value in the document.
+	ErrPointerNotFound = errors.New("jtdinfer: JSON Pointer not found")
+
+	// ErrNotArray is returned when the value used as rows isn't an array.
+	ErrNotArray = errors.New("jtdinfer: value is not an array")
+)
+
+// pointerUnescaper unescapes JSON Pointer reference tokens where `~1` must be
+// replaced before `~0`.
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// ReaderOptions configures how values are read from a reader.
+type ReaderOptions struct {
+	// Array will use each element in a top-level array as a row instead of
+	// the whole document. The elements are decoded one by one so the document
+	// is never fully read into memory.
+	Array bool
+	// Pointer is a JSON Pointer, e.g. `/data/items`, to a nested array to use
+	// as rows. Setting a pointer implies `Array`. Since the reader is never
+	// read backwards the first value is used for duplicate keys, unlike
+	// `encoding/json` which keeps the last.
+	Pointer string
+}
+
+// InferReader will infer each JSON value in the reader as a row, e.g. newline
+// delimited JSON. See `InferReaderContext` for details.
+func InferReader(r io.Reader, opts ReaderOptions, hints Hints) (*Inferrer, error) {
+	return InferReaderContext(context.Background(), r, opts, hints)
+}
+
+// InferReaderContext will infer each JSON value in the reader as a row, e.g.
+// newline delimited JSON, or if `Array` or `Pointer` is set each element of
+// the array. Numbers are decoded as `json.Number`. When reading an array the
+// reader is only read until the end of the array. If an error occurs the
+// inferrer is returned with the state it had before the failing row.
+func InferReaderContext(ctx context.Context, r io.Reader, opts ReaderOptions, hints Hints) (*Inferrer, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	if opts.Array || opts.Pointer != "" {
+		return inferArray(ctx, decoder, opts.Pointer, NewInferrer(hints), false)
+	}
+
+	inferrer := NewInferrer(hints)
+
+	t := getTape()
+	defer putTape(t)
+
+	// Each value is only validated by the decoder and then read as tokens to
+	// not decode it into maps and slices.
+	var raw json.RawMessage
+
+	for {
+		offset := decoder.InputOffset()
+
+		err := decoder.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			return inferrer, nil
+		}
+
+		if err != nil {
+			return inferrer, fmt.Errorf("jtdinfer: invalid JSON at offset %d: %w", offset, err)
+		}
+
+		value, err := t.decode(raw)
+		if err != nil {
+			return inferrer, fmt.Errorf("jtdinfer: invalid JSON at offset %d: %w", offset, err)
+		}
+
+		inferrer, err = inferrer.inferContext(ctx, value, t)
+		if err != nil {
+			return inferrer, err
+		}
+	}
+}
+
+// InferArrayStrings will infer each element in the top-level array of each
+// row, or the array the JSON Pointer points to if not empty, as a separate
+// value. See `InferArrayStringsContext` for details.
+func InferArrayStrings(rows []string, pointer string, hints Hints) (*Inferrer, error) {
+	return InferArrayStringsContext(context.Background(), rows, pointer, hints)
+}
+
+// InferArrayStringsContext works like `InferArrayStrings` but will return an
+// error if the context is cancelled. Each row must be a single JSON value and
+// like for `ReaderOptions.Pointer` the first value is used for duplicate keys
+// in the path to the array. If an error occurs the inferrer is returned with
+// the state it had before the failing element.
+func InferArrayStringsContext(ctx context.Context, rows []string, pointer string, hints Hints) (*Inferrer, error) {
+	inferrer := NewInferrer(hints)
+
+	for idx, row := range rows {
+		decoder := json.NewDecoder(strings.NewReader(row))
+		decoder.UseNumber()
+
+		var err error
+
+		inferrer, err = inferArray(ctx, decoder, pointer, inferrer, true)
+		if err != nil {
+			return inferrer, fmt.Errorf("jtdinfer: invalid row at index %d: %w", idx, err)
+		}
+	}
+
+	return inferrer, nil
+}
+
+// inferArray seeks to the array at the pointer and infers each element. If
+// complete is set the rest of the document is read and must not be followed by
+// any data.
+func inferArray(
+	ctx context.Context,
+	decoder *json.Decoder,
+	pointer string,
+	inferrer *Inferrer,
+	complete bool,
+) (*Inferrer, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return inferrer, err
+	}
+
+	if err := seekPointer(decoder, tokens); err != nil {
+		return inferrer, err
+	}
+
+	token, err := decoder.Token()
+	if err != nil {
+		return inferrer, jsonError(decoder, err)
+	}
+
+	if token != json.Delim('[') {
+		return inferrer, ErrNotArray
+	}
+
+	t := getTape()
+	defer putTape(t)
+
+	var raw json.RawMessage
+
+	for decoder.More() {
+		offset := decoder.InputOffset()
+
+		if err := decoder.Decode(&raw); err != nil {
+			return inferrer, fmt.Errorf("jtdinfer: invalid JSON at offset %d: %w", offset, err)
+		}
+
+		value, err := t.decode(raw)
+		if err != nil {
+			return inferrer, fmt.Errorf("jtdinfer: invalid JSON at offset %d: %w", offset, err)
+		}
+
+		inferrer, err = inferrer.inferContext(ctx, value, t)
+		if err != nil {
+			return inferrer, err
+		}
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return inferrer, jsonError(decoder, err)
+	}
+
+	if complete {
+		if err := finishDocument(decoder, len(tokens)); err != nil {
+			return inferrer, err
+		}
+	}
+
+	return inferrer, nil
+}
+
+// finishDocument reads the rest of the document the decoder is depth levels
+// into and returns an error if there's any data after it.
+func finishDocument(decoder *json.Decoder, depth int) error {
+	for depth > 0 {
+		token, err := decoder.Token()
+		if err != nil {
+			return jsonError(decoder, err)
+		}
+
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return fmt.Errorf("jtdinfer: invalid JSON at offset %d: %w", decoder.InputOffset(), errTrailingData)
+	}
+
+	return nil
+}
+
+// parsePointer splits the JSON Pointer into unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("jtdinfer: invalid JSON Pointer %q, must start with /", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = pointerUnescaper.Replace(token)
+	}
+
+	return tokens, nil
+}
+
+// seekPointer reads the decoder until the next value is the value for the
+// reference tokens.
+func seekPointer(decoder *json.Decoder, tokens []string) error {
+	for depth, want := range tokens {
+		token, err := decoder.Token()
+		if err != nil {
+			return jsonError(decoder, err)
+		}
+
+		notFound := func() error {
+			return fmt.Errorf("%w: /%s", ErrPointerNotFound, strings.Join(tokens[:depth+1], "/"))
+		}
+
+		switch token {
+		case json.Delim('{'):
+			found, err := seekKey(decoder, want)
+			if err != nil {
+				return err
+			}
+
+			if !found {
+				return notFound()
+			}
+		case json.Delim('['):
+			index, err := strconv.Atoi(want)
+			if err != nil || index < 0 {
+				return notFound()
+			}
+
+			for ; index > 0 && decoder.More(); index-- {
+				if err := skipValue(decoder); err != nil {
+					return err
+				}
+			}
+
+			if !decoder.More() {
+				return notFound()
+			}
+		default:
+			return notFound()
+		}
+	}
+
+	return nil
+}
+
+// seekKey reads the object until the next value is the value for the first
+// occurrence of the key.
+func seekKey(decoder *json.Decoder, key string) (bool, error) {
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return false, jsonError(decoder, err)
+		}
+
+		if token == key {
+			return true, nil
+		}
+
+		if err := skipValue(decoder); err != nil {
+			return false, err
+		}
+	}
+
+	return false, nil
+}
+
+// skipValue reads the next value without decoding it.
+func skipValue(decoder *json.Decoder) error {
+	depth := 0
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return jsonError(decoder, err)
+		}
+
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+func jsonError(decoder *json.Decoder, err error) error {
+	return fmt.Errorf("jtdinfer: invalid JSON at offset %d: %w", decoder.InputOffset(), unexpectedEOF(err))
+}
@@ -0,0 +1,150 @@
+package jtdinfer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	jtd "github.com/jsontypedef/json-typedef-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInferReader(t *testing.T) {
+	row := Schema{
+		Properties: map[string]Schema{
+			"id": {Type: jtd.TypeUint8},
+		},
+	}
+
+	for _, tc := range []struct {
+		description string
+		input       string
+		opts        ReaderOptions
+		expected    Schema
+	}{
+		{
+			description: "newline delimited",
+			input:       "{\"id\": 1}\n{\"id\": 2}\n",
+			expected:    row,
+		},
+		{
+			description: "array without option",
+			input:       `[{"id": 1}, {"id": 2}]`,
+			expected:    Schema{Elements: &row},
+		},
+		{
+			description: "array",
+			input:       `[{"id": 1}, {"id": 2}]`,
+			opts:        ReaderOptions{Array: true},
+			expected:    row,
+		},
+		{
+			description: "pointer",
+			input:       `{"meta": {"items": [1]}, "data": {"a/b": [0, [{"id": 1}, {"id": 2}]]}, "after": [`,
+			opts:        ReaderOptions{Pointer: "/data/a~1b/1"},
+			expected:    row,
+		},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			inferrer, err := InferReader(strings.NewReader(tc.input), tc.opts, WithoutHints())
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, inferrer.IntoSchema())
+		})
+	}
+}
+
+func TestInferReaderErrors(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		input       string
+		opts        ReaderOptions
+		err         error
+		message     string
+	}{
+		{
+			description: "missing key",
+			input:       `{"data": {"other": []}}`,
+			opts:        ReaderOptions{Pointer: "/data/items"},
+			err:         ErrPointerNotFound,
+			message:     "jtdinfer: JSON Pointer not found: /data/items",
+		},
+		{
+			description: "index out of range",
+			input:       `{"data": [[1]]}`,
+			opts:        ReaderOptions{Pointer: "/data/1"},
+			err:         ErrPointerNotFound,
+			message:     "jtdinfer: JSON Pointer not found: /data/1",
+		},
+		{
+			description: "not an array",
+			input:       `{"data": {}}`,
+			opts:        ReaderOptions{Pointer: "/data"},
+			err:         ErrNotArray,
+			message:     "jtdinfer: value is not an array",
+		},
+		{
+			description: "invalid element",
+			input:       `[{"id": 1}, {"id": }]`,
+			opts:        ReaderOptions{Array: true},
+			message:     "jtdinfer: invalid JSON at offset 10: ",
+		},
+		{
+			description: "truncated array",
+			input:       `[{"id": 1}`,
+			opts:        ReaderOptions{Array: true},
+			message:     "jtdinfer: invalid JSON at offset 10: ",
+		},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			_, err := InferReader(strings.NewReader(tc.input), tc.opts, WithoutHints())
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+			}
+
+			assert.ErrorContains(t, err, tc.message)
+		})
+	}
+}
+
+func TestInferArrayStrings(t *testing.T) {
+	inferrer, err := InferArrayStrings(
+		[]string{`{"items": [{"id": 1}]}`, `{"items": [{"id": 2, "name": "x"}]}`},
+		"/items",
+		WithoutHints(),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, Schema{
+		Properties:         map[string]Schema{"id": {Type: jtd.TypeUint8}},
+		OptionalProperties: map[string]Schema{"name": {Type: jtd.TypeString}},
+	}, inferrer.IntoSchema())
+
+	_, err = InferArrayStrings([]string{`[1]`, `{}`}, "", WithoutHints())
+	require.ErrorIs(t, err, ErrNotArray)
+	assert.EqualError(t, err, "jtdinfer: invalid row at index 1: jtdinfer: value is not an array")
+
+	for _, row := range []string{`[1] [2]`, `[1] x`, `{"items": [1]} {}`, `{"items": [1], "x": }`} {
+		_, err = InferArrayStrings([]string{row}, "/items", WithoutHints())
+		require.Error(t, err, row)
+	}
+
+	_, err = InferArrayStrings([]string{`[1] [2]`}, "", WithoutHints())
+	require.ErrorIs(t, err, errTrailingData)
+
+	// The rest of the document is read after the array.
+	inferrer, err = InferArrayStrings([]string{` {"items": [1], "x": {"y": [2]}} `}, "/items", WithoutHints())
+	require.NoError(t, err)
+	assert.Equal(t, Schema{Type: jtd.TypeUint8}, inferrer.IntoSchema())
+
+	// The first value is used for duplicate keys in the path.
+	inferrer, err = InferArrayStrings([]string{`{"items": [1], "items": ["a"]}`}, "/items", WithoutHints())
+	require.NoError(t, err)
+	assert.Equal(t, Schema{Type: jtd.TypeUint8}, inferrer.IntoSchema())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = InferArrayStringsContext(ctx, []string{`[1]`}, "", WithoutHints())
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,111 @@
+package jtdinfer
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// SyncInferrer is an inferrer that is safe for concurrent use. Values are
+// inferred into one of several shards, each with its own state and lock, so
+// goroutines rarely wait for each other. The shards are only merged when a
+// snapshot is taken.
+//
+// Limits are enforced per shard, e.g. `MaxNodes` is the maximum number of nodes
+// for each shard and not for the merged schema.
+type SyncInferrer struct {
+	hints  Hints
+	shards []syncShard
+	next   atomic.Uint64
+}
+
+type syncShard struct {
+	mu       sync.Mutex
+	inferrer *Inferrer
+
+	// Pad the shard to a cache line to not have goroutines locking different
+	// shards invalidate each others cache.
+	_ [48]byte
+}
+
+// NewSyncInferrer will create a new `SyncInferrer` with one shard per
+// available CPU.
+func NewSyncInferrer(hints Hints) *SyncInferrer {
+	shards := make([]syncShard, runtime.GOMAXPROCS(0))
+	for i := range shards {
+		shards[i].inferrer = NewInferrer(hints)
+	}
+
+	return &SyncInferrer{
+		hints:  hints,
+		shards: shards,
+	}
+}
+
+// Observe will infer the value. It's safe to call from multiple goroutines. If
+// a limit is reached and the `LimitPolicy` is to return an error the value is
+// ignored.
+func (s *SyncInferrer) Observe(value any) {
+	_ = s.ObserveContext(context.Background(), value)
+}
+
+// ObserveContext works like `Observe` but will return an error if the context
+// is cancelled or if a limit is reached and the `LimitPolicy` is to return an
+// error.
+func (s *SyncInferrer) ObserveContext(ctx context.Context, value any) error {
+	shard := s.lockShard()
+	defer shard.mu.Unlock()
+
+	inferrer, err := shard.inferrer.InferContext(ctx, value)
+	shard.inferrer = inferrer
+
+	return err
+}
+
+// lockShard locks and returns the next shard that isn't locked or, if all are
+// locked, waits for the next shard in turn.
+func (s *SyncInferrer) lockShard() *syncShard {
+	start := s.next.Add(1)
+
+	for i := 0; i < len(s.shards); i++ {
+		shard := &s.shards[(start+uint64(i))%uint64(len(s.shards))]
+		if shard.mu.TryLock() {
+			return shard
+		}
+	}
+
+	shard := &s.shards[start%uint64(len(s.shards))]
+	shard.mu.Lock()
+
+	return shard
+}
+
+// Inferrer will return a new `Inferrer` with the state of all shards merged.
+// All shards are locked while merging so the state is consistent, i.e. it
+// includes every value where `Observe` returned before the call. The returned
+// inferrer doesn't share any state with the `SyncInferrer`.
+func (s *SyncInferrer) Inferrer() *Inferrer {
+	for i := range s.shards {
+		s.shards[i].mu.Lock()
+	}
+
+	defer func() {
+		for i := range s.shards {
+			s.shards[i].mu.Unlock()
+		}
+	}()
+
+	merged := NewInferrer(s.hints)
+	for i := range s.shards {
+		merged = merged.Merge(s.shards[i].inferrer)
+	}
+
+	return merged
+}
+
+// Snapshot will convert the merged state of all shards to a `Schema`, see
+// `Inferrer`.
+func (s *SyncInferrer) Snapshot() Schema {
+	return s.Inferrer().IntoSchema()
+}
@@ -0,0 +1,94 @@
+package jtdinfer
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+
+	jtd "github.com/jsontypedef/json-typedef-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncInferrer(t *testing.T) {
+	hints := Hints{
+		Enums:         NewHintSet().Add([]string{"status"}),
+		Discriminator: NewHintSet().Add([]string{"event", "type"}),
+	}
+
+	inferrer := NewSyncInferrer(hints)
+
+	var wg sync.WaitGroup
+
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+
+		go func(g int) {
+			defer wg.Done()
+
+			for i := 0; i < 200; i++ {
+				value := map[string]any{
+					"id":     i,
+					"status": "status" + strconv.Itoa(i%3),
+					"event": map[string]any{
+						"type": "type" + strconv.Itoa(g%2),
+					},
+				}
+
+				if i%2 == 0 {
+					value["extra"] = "x"
+				}
+
+				inferrer.Observe(value)
+
+				if i%50 == 0 {
+					_ = inferrer.Snapshot()
+				}
+			}
+		}(g)
+	}
+
+	wg.Wait()
+
+	schema := inferrer.Snapshot()
+
+	assert.Equal(t, jtd.Type(jtd.TypeUint8), schema.Properties["id"].Type)
+	assert.ElementsMatch(t, []string{"status0", "status1", "status2"}, schema.Properties["status"].Enum)
+	assert.Equal(t, "type", schema.Properties["event"].Discriminator)
+	assert.Len(t, schema.Properties["event"].Mapping, 2)
+	assert.Contains(t, schema.OptionalProperties, "extra")
+}
+
+func TestSyncInferrerMatchesInferrer(t *testing.T) {
+	rows := []string{
+		`{"a": 1, "b": [true]}`,
+		`{"a": -5, "c": "2020-01-01T00:00:00Z"}`,
+		`{"a": null, "b": [], "c": "x"}`,
+	}
+
+	syncInferrer := NewSyncInferrer(WithoutHints())
+
+	for _, row := range rows {
+		value, err := unmarshalRow(row)
+		require.NoError(t, err)
+
+		syncInferrer.Observe(value)
+	}
+
+	assert.Equal(t, InferStrings(rows, WithoutHints()).IntoSchema(), syncInferrer.Snapshot())
+}
+
+func TestSyncInferrerContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	inferrer := NewSyncInferrer(WithoutHints())
+	require.ErrorIs(t, inferrer.ObserveContext(ctx, 1), context.Canceled)
+	assert.Equal(t, Schema{}, inferrer.Snapshot())
+
+	limited := NewSyncInferrer(Hints{Limits: Limits{MaxDepth: 1}})
+
+	var limitErr *LimitError
+	require.ErrorAs(t, limited.ObserveContext(context.Background(), [][]int{{1}}), &limitErr)
+}
@@ -0,0 +1,635 @@
+package jtdinfer
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+// maxTapeDepth is the maximum nesting depth, the same as for `encoding/json`.
+const maxTapeDepth = 10000
+
+// maxTapeKeys is the number of distinct object keys to share between rows so
+// keys aren't allocated for every row. Keys beyond this are allocated every
+// time so the memory stays bounded for unbounded keys such as map keys.
+const maxTapeKeys = 4096
+
+type tokenKind uint8
+
+const (
+	tokenNull tokenKind = iota
+	tokenFalse
+	tokenTrue
+	tokenNumber
+	tokenString
+	tokenKey
+	tokenObject
+	tokenArray
+)
+
+// tapeToken is a token in a `tape`. Objects and arrays are followed by their
+// keys and values and end is the index after the last of them.
+type tapeToken struct {
+	kind  tokenKind
+	value string
+	end   int
+	// ref is the index of the object or array in the tape.
+	ref int
+	// duplicate is set for keys that are followed by the same key in the
+	// same object.
+	duplicate bool
+	// invalidUTF8 is set for keys and strings with bytes that aren't valid
+	// UTF-8.
+	invalidUTF8 bool
+}
+
+// tape is a JSON value read as a flat list of tokens. This is used to infer
+// JSON without decoding it into maps and slices first. The tape is reused
+// between values so values read from the tape are only valid until the next
+// value is read.
+type tape struct {
+	tokens  []tapeToken
+	objects []tapeObject
+	arrays  []tapeArray
+	keys    map[string]string
+
+	// keyIndexes holds the indexes of the keys of the objects being read
+	// and seen is used to find duplicate keys in large objects.
+	keyIndexes []int
+	seen       map[string]struct{}
+
+	// issues is the number of duplicate keys and invalid strings.
+	issues int
+}
+
+// tapeObject is an object in a `tape`. Keys followed by the same key are
+// skipped, the same way as the last value is kept when decoding to a map. The
+// key to skip is set for objects with the discriminator removed.
+type tapeObject struct {
+	tape    *tape
+	idx     int
+	len     int
+	skip    string
+	hasSkip bool
+}
+
+// tapeArray is an array in a `tape`.
+type tapeArray struct {
+	tape *tape
+	idx  int
+	len  int
+}
+
+// tapes holds tapes that are reused between rows to keep the allocations down.
+// The pool is shared by all inferrers and readers since a tape is only used
+// while reading a single row.
+var tapes = sync.Pool{ //nolint:gochecknoglobals // Shared to reuse tapes between all inferrers.
+	New: func() any {
+		return newTape()
+	},
+}
+
+func newTape() *tape {
+	return &tape{keys: map[string]string{}}
+}
+
+// getTape returns a tape from the pool, use `putTape` to return it.
+func getTape() *tape {
+	if t, ok := tapes.Get().(*tape); ok {
+		return t
+	}
+
+	return newTape()
+}
+
+// putTape returns the tape to the pool.
+func putTape(t *tape) {
+	tapes.Put(t)
+}
+
+// decode reads the data and returns the value. The data is decoded with
+// `unmarshalRow` if it can't be read to get the same value or error.
+func (t *tape) decode(data []byte) (any, error) {
+	if !t.read(data) {
+		t.issues = 0
+		return unmarshalRow(string(data))
+	}
+
+	return t.value(0), nil
+}
+
+// read reads the data as tokens and returns false if the data isn't a single
+// valid JSON value.
+func (t *tape) read(data []byte) bool {
+	t.tokens = t.tokens[:0]
+	t.objects = t.objects[:0]
+	t.arrays = t.arrays[:0]
+	t.keyIndexes = t.keyIndexes[:0]
+	t.issues = 0
+
+	pos, ok := t.readValue(data, skipSpace(data, 0), 0)
+	if !ok {
+		return false
+	}
+
+	return skipSpace(data, pos) == len(data)
+}
+
+// readValue reads the value at the position and returns the position after
+// it.
+func (t *tape) readValue(data []byte, pos, depth int) (int, bool) {
+	if pos >= len(data) {
+		return pos, false
+	}
+
+	switch c := data[pos]; {
+	case c == '{':
+		return t.readObject(data, pos, depth+1)
+	case c == '[':
+		return t.readArray(data, pos, depth+1)
+	case c == '"':
+		end, value, valid, ok := readString(data, pos)
+		if !ok {
+			return pos, false
+		}
+
+		t.tokens = append(t.tokens, tapeToken{kind: tokenString, value: value, invalidUTF8: !valid})
+		if !valid {
+			t.issues++
+		}
+
+		return end, true
+	case c == '-' || (c >= '0' && c <= '9'):
+		end, ok := readNumber(data, pos)
+		if !ok {
+			return pos, false
+		}
+
+		t.tokens = append(t.tokens, tapeToken{kind: tokenNumber, value: string(data[pos:end])})
+
+		return end, true
+	case c == 'n' && hasPrefix(data[pos:], "null"):
+		t.tokens = append(t.tokens, tapeToken{kind: tokenNull})
+		return pos + len("null"), true
+	case c == 't' && hasPrefix(data[pos:], "true"):
+		t.tokens = append(t.tokens, tapeToken{kind: tokenTrue})
+		return pos + len("true"), true
+	case c == 'f' && hasPrefix(data[pos:], "false"):
+		t.tokens = append(t.tokens, tapeToken{kind: tokenFalse})
+		return pos + len("false"), true
+	default:
+		return pos, false
+	}
+}
+
+func (t *tape) readObject(data []byte, pos, depth int) (int, bool) {
+	if depth > maxTapeDepth {
+		return pos, false
+	}
+
+	idx := len(t.tokens)
+	t.tokens = append(t.tokens, tapeToken{kind: tokenObject, ref: len(t.objects)})
+	t.objects = append(t.objects, tapeObject{tape: t, idx: idx})
+	firstKey := len(t.keyIndexes)
+
+	pos = skipSpace(data, pos+1)
+	if pos < len(data) && data[pos] == '}' {
+		t.tokens[idx].end = len(t.tokens)
+		return pos + 1, true
+	}
+
+	for {
+		if pos >= len(data) || data[pos] != '"' {
+			return pos, false
+		}
+
+		end, key, valid, ok := t.readKey(data, pos)
+		if !ok {
+			return pos, false
+		}
+
+		t.keyIndexes = append(t.keyIndexes, len(t.tokens))
+		t.tokens = append(t.tokens, tapeToken{kind: tokenKey, value: key, invalidUTF8: !valid})
+
+		if !valid {
+			t.issues++
+		}
+
+		pos = skipSpace(data, end)
+		if pos >= len(data) || data[pos] != ':' {
+			return pos, false
+		}
+
+		pos, ok = t.readValue(data, skipSpace(data, pos+1), depth)
+		if !ok {
+			return pos, false
+		}
+
+		pos = skipSpace(data, pos)
+		if pos >= len(data) {
+			return pos, false
+		}
+
+		if data[pos] == '}' {
+			break
+		}
+
+		if data[pos] != ',' {
+			return pos, false
+		}
+
+		pos = skipSpace(data, pos+1)
+	}
+
+	object := &t.objects[t.tokens[idx].ref]
+	duplicates := t.markDuplicates(t.keyIndexes[firstKey:])
+	object.len = len(t.keyIndexes) - firstKey - duplicates
+	t.issues += duplicates
+	t.keyIndexes = t.keyIndexes[:firstKey]
+	t.tokens[idx].end = len(t.tokens)
+
+	return pos + 1, true
+}
+
+// markDuplicates marks the keys that are followed by the same key and returns
+// the number of marked keys.
+func (t *tape) markDuplicates(keyIndexes []int) int {
+	duplicates := 0
+
+	// Small objects are compared key by key to not use the map.
+	if len(keyIndexes) <= 16 {
+		for i, a := range keyIndexes {
+			for _, b := range keyIndexes[i+1:] {
+				if t.tokens[a].value == t.tokens[b].value {
+					t.tokens[a].duplicate = true
+					duplicates++
+
+					break
+				}
+			}
+		}
+
+		return duplicates
+	}
+
+	if t.seen == nil {
+		t.seen = map[string]struct{}{}
+	}
+
+	defer clear(t.seen)
+
+	for i := len(keyIndexes) - 1; i >= 0; i-- {
+		token := &t.tokens[keyIndexes[i]]
+		if _, ok := t.seen[token.value]; ok {
+			token.duplicate = true
+			duplicates++
+
+			continue
+		}
+
+		t.seen[token.value] = struct{}{}
+	}
+
+	return duplicates
+}
+
+func (t *tape) readArray(data []byte, pos, depth int) (int, bool) {
+	if depth > maxTapeDepth {
+		return pos, false
+	}
+
+	idx := len(t.tokens)
+	t.tokens = append(t.tokens, tapeToken{kind: tokenArray, ref: len(t.arrays)})
+	t.arrays = append(t.arrays, tapeArray{tape: t, idx: idx})
+	elements := 0
+
+	pos = skipSpace(data, pos+1)
+	if pos < len(data) && data[pos] == ']' {
+		t.tokens[idx].end = len(t.tokens)
+		return pos + 1, true
+	}
+
+	for {
+		var ok bool
+
+		pos, ok = t.readValue(data, pos, depth)
+		if !ok {
+			return pos, false
+		}
+
+		elements++
+
+		pos = skipSpace(data, pos)
+		if pos >= len(data) {
+			return pos, false
+		}
+
+		if data[pos] == ']' {
+			break
+		}
+
+		if data[pos] != ',' {
+			return pos, false
+		}
+
+		pos = skipSpace(data, pos+1)
+	}
+
+	t.arrays[t.tokens[idx].ref].len = elements
+	t.tokens[idx].end = len(t.tokens)
+
+	return pos + 1, true
+}
+
+// readKey reads a string and returns the same string for the same key in
+// every row. The returned booleans tells if the key is valid UTF-8 and if the
+// string could be read.
+func (t *tape) readKey(data []byte, pos int) (int, string, bool, bool) {
+	end, escaped, valid, ok := scanString(data, pos)
+	if !ok {
+		return pos, "", false, false
+	}
+
+	raw := data[pos+1 : end-1]
+	if escaped || !valid {
+		key, ok := unquote(data[pos:end])
+		return end, key, valid, ok
+	}
+
+	if key, ok := t.keys[string(raw)]; ok {
+		return end, key, true, true
+	}
+
+	key := string(raw)
+	if len(t.keys) < maxTapeKeys {
+		t.keys[key] = key
+	}
+
+	return end, key, true, true
+}
+
+// readString reads the string at the position. The returned booleans tells if
+// the string is valid UTF-8 and if the string could be read.
+func readString(data []byte, pos int) (int, string, bool, bool) {
+	end, escaped, valid, ok := scanString(data, pos)
+	if !ok {
+		return pos, "", false, false
+	}
+
+	if escaped || !valid {
+		value, ok := unquote(data[pos:end])
+		return end, value, valid, ok
+	}
+
+	return end, string(data[pos+1 : end-1]), true, true
+}
+
+// scanString returns the position after the string at the position, if the
+// string has any escapes and if it's valid UTF-8. Strings without escapes that
+// are valid UTF-8 can be used as is.
+func scanString(data []byte, pos int) (int, bool, bool, bool) {
+	escaped, ascii := false, true
+
+	for i := pos + 1; i < len(data); i++ {
+		switch c := data[i]; {
+		case c == '"':
+			return i + 1, escaped, ascii || utf8.Valid(data[pos+1:i]), true
+		case c == '\\':
+			escaped = true
+			i++
+
+			if i >= len(data) {
+				return pos, false, false, false
+			}
+
+			switch data[i] {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+			case 'u':
+				if i+4 >= len(data) {
+					return pos, false, false, false
+				}
+
+				for _, h := range data[i+1 : i+5] {
+					if !isHex(h) {
+						return pos, false, false, false
+					}
+				}
+
+				i += 4
+			default:
+				return pos, false, false, false
+			}
+		case c < 0x20:
+			return pos, false, false, false
+		case c >= utf8.RuneSelf:
+			ascii = false
+		}
+	}
+
+	return pos, false, false, false
+}
+
+// unquote decodes a string with escapes or invalid UTF-8 the same way as
+// `encoding/json`.
+func unquote(quoted []byte) (string, bool) {
+	var s string
+	if err := json.Unmarshal(quoted, &s); err != nil {
+		return "", false
+	}
+
+	return s, true
+}
+
+// readNumber returns the position after the number at the position.
+func readNumber(data []byte, pos int) (int, bool) {
+	i := pos
+	if data[i] == '-' {
+		i++
+	}
+
+	digits := func() int {
+		start := i
+		for i < len(data) && data[i] >= '0' && data[i] <= '9' {
+			i++
+		}
+
+		return i - start
+	}
+
+	switch {
+	case i < len(data) && data[i] == '0':
+		i++
+	case digits() == 0:
+		return pos, false
+	}
+
+	if i < len(data) && data[i] == '.' {
+		i++
+
+		if digits() == 0 {
+			return pos, false
+		}
+	}
+
+	if i < len(data) && (data[i] == 'e' || data[i] == 'E') {
+		i++
+
+		if i < len(data) && (data[i] == '+' || data[i] == '-') {
+			i++
+		}
+
+		if digits() == 0 {
+			return pos, false
+		}
+	}
+
+	return i, true
+}
+
+func hasPrefix(data []byte, prefix string) bool {
+	return len(data) >= len(prefix) && string(data[:len(prefix)]) == prefix
+}
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func skipSpace(data []byte, pos int) int {
+	for pos < len(data) {
+		switch data[pos] {
+		case ' ', '\t', '\n', '\r':
+			pos++
+		default:
+			return pos
+		}
+	}
+
+	return pos
+}
+
+// value returns the value at the index as it would be decoded by
+// `unmarshalRow` except that objects and arrays are returned as a
+// `*tapeObject` and `*tapeArray`.
+func (t *tape) value(idx int) any {
+	token := &t.tokens[idx]
+
+	switch token.kind {
+	case tokenFalse:
+		return false
+	case tokenTrue:
+		return true
+	case tokenNumber:
+		return json.Number(token.value)
+	case tokenString:
+		return token.value
+	case tokenObject:
+		return &t.objects[token.ref]
+	case tokenArray:
+		return &t.arrays[token.ref]
+	default:
+		return nil
+	}
+}
+
+// next returns the index after the value at the index.
+func (t *tape) next(idx int) int {
+	if kind := t.tokens[idx].kind; kind == tokenObject || kind == tokenArray {
+		return t.tokens[idx].end
+	}
+
+	return idx + 1
+}
+
+// each calls fn with each key and value in the object.
+func (o *tapeObject) each(fn func(key string, value any)) {
+	t := o.tape
+
+	for idx := o.idx + 1; idx < t.tokens[o.idx].end; {
+		key := &t.tokens[idx]
+		valueIdx := idx + 1
+		idx = t.next(valueIdx)
+
+		if key.duplicate || (o.hasSkip && key.value == o.skip) {
+			continue
+		}
+
+		fn(key.value, t.value(valueIdx))
+	}
+}
+
+// get returns the value for the key.
+func (o *tapeObject) get(key string) (any, bool) {
+	if o.hasSkip && key == o.skip {
+		return nil, false
+	}
+
+	t := o.tape
+
+	for idx := o.idx + 1; idx < t.tokens[o.idx].end; idx = t.next(idx + 1) {
+		if token := &t.tokens[idx]; !token.duplicate && token.value == key {
+			return t.value(idx + 1), true
+		}
+	}
+
+	return nil, false
+}
+
+// without returns the object without the key.
+func (o *tapeObject) without(key string) *tapeObject {
+	without := *o
+	without.skip, without.hasSkip = key, true
+
+	if _, ok := o.get(key); ok {
+		without.len--
+	}
+
+	return &without
+}
+
+// each calls fn with the index and value of each element in the array.
+func (a *tapeArray) each(fn func(idx int, value any)) {
+	t := a.tape
+
+	for i, idx := 0, a.idx+1; idx < t.tokens[a.idx].end; i, idx = i+1, t.next(idx) {
+		fn(i, t.value(idx))
+	}
+}
+
+// diagnose calls fn with the path and kind of each duplicate key and each key
+// or string with invalid UTF-8.
+func (t *tape) diagnose(fn func(path []string, kind DiagnosticKind)) {
+	if t.issues > 0 {
+		t.diagnoseValue(0, nil, fn)
+	}
+}
+
+func (t *tape) diagnoseValue(idx int, path []string, fn func(path []string, kind DiagnosticKind)) {
+	token := &t.tokens[idx]
+
+	switch token.kind {
+	case tokenString:
+		if token.invalidUTF8 {
+			fn(path, DiagnosticInvalidUTF8)
+		}
+	case tokenObject:
+		for k := idx + 1; k < token.end; k = t.next(k + 1) {
+			key := &t.tokens[k]
+			keyPath := append(path[:len(path):len(path)], key.value)
+
+			if key.duplicate {
+				fn(keyPath, DiagnosticDuplicateKey)
+			}
+
+			if key.invalidUTF8 {
+				fn(keyPath, DiagnosticInvalidUTF8)
+			}
+
+			t.diagnoseValue(k+1, keyPath, fn)
+		}
+	case tokenArray:
+		for i, k := 0, idx+1; k < token.end; i, k = i+1, t.next(k) {
+			t.diagnoseValue(k, append(path[:len(path):len(path)], strconv.Itoa(i)), fn)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package jtdinfer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// plain converts objects and arrays in a tape to maps and slices.
+func plain(value any) any {
+	switch v := value.(type) {
+	case *tapeObject:
+		m := map[string]any{}
+		v.each(func(key string, value any) {
+			m[key] = plain(value)
+		})
+
+		return m
+	case *tapeArray:
+		s := []any{}
+		v.each(func(_ int, value any) {
+			s = append(s, plain(value))
+		})
+
+		return s
+	default:
+		return value
+	}
+}
+
+func TestTapeRead(t *testing.T) {
+	for _, input := range []string{
+		`null`,
+		` true `,
+		`false`,
+		`0`,
+		`-1.5e+10`,
+		`12345678901234567890123`,
+		`""`,
+		`"a\"b\\c\/d\b\f\n\r\t"`,
+		`"å😀"`,
+		`"\ud800"`,
+		"\"\xff\xfe\"",
+		`"åäö"`,
+		`{}`,
+		`[]`,
+		`{"a": 1, "b": [1, "x", null, {}], "c": {"d": []}}`,
+		`{"a": 1, "a": 2}`,
+		`{"a": {"b": 1}, "b": 1, "a": {"c": 2}}`,
+		`{"ab": 1, "ab": 2}`,
+		`{"` + strings.Repeat(`a": 1, "`, 20) + `b": 2}`,
+		`[[[[[[]]]]]]`,
+		// Invalid values.
+		``,
+		` `,
+		`nul`,
+		`True`,
+		`01`,
+		`-`,
+		`1.`,
+		`1e`,
+		`.5`,
+		`+1`,
+		`"a`,
+		"\"a\tb\"",
+		`"\x"`,
+		`"\u12"`,
+		`{`,
+		`{"a"}`,
+		`{"a": 1,}`,
+		`{a: 1}`,
+		`[1,]`,
+		`[1 2]`,
+		`{} {}`,
+		`1 x`,
+		strings.Repeat("[", maxTapeDepth+1) + strings.Repeat("]", maxTapeDepth+1),
+	} {
+		t.Run(input, func(t *testing.T) {
+			tp := &tape{keys: map[string]string{}}
+
+			ok := tp.read([]byte(input))
+			require.Equal(t, json.Valid([]byte(input)), ok)
+
+			want, err := unmarshalRow(input)
+			if !ok {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, want, plain(tp.value(0)))
+		})
+	}
+}
+
+func TestTapeReuse(t *testing.T) {
+	tp := &tape{keys: map[string]string{}}
+
+	require.True(t, tp.read([]byte(`{"a": [1, 2], "b": {"c": "x"}}`)))
+	require.True(t, tp.read([]byte(`{"d": true}`)))
+
+	assert.Equal(t, map[string]any{"d": true}, plain(tp.value(0)))
+}
+
+func TestInferJSON(t *testing.T) {
+	hints := Hints{
+		Enums:         NewHintSet().Add([]string{"status"}),
+		Values:        NewHintSet().Add([]string{"labels"}),
+		Discriminator: NewHintSet().Add([]string{"event", "type"}),
+	}
+
+	rows := []string{
+		`{"status": "a", "event": {"type": "x", "v": 1}, "labels": {"a": 1}}`,
+		`{"status": "b", "status": "c", "event": {"v": 2, "type": "y", "type": "x"}}`,
+		`{"event": {"type": "y", "w": [1, 2.5, -3]}, "labels": {"b": null, "b": 2}}`,
+		`{"status": "a", "event": {"type": "x"}, "extra": [{"a": 1}, {"a": "x", "b": true}]}`,
+		"{\"status\": \"\xff\", \"labels\": {}}",
+	}
+
+	for name, corpus := range benchmarkCorpora(50) {
+		rows = append(rows, corpus...)
+		rows = append(rows, `{"`+name+`": 1}`)
+	}
+
+	want := NewInferrer(hints)
+	got := NewInferrer(hints)
+
+	for _, row := range rows {
+		value, err := unmarshalRow(row)
+		require.NoError(t, err)
+
+		want = want.Infer(value)
+
+		got, err = got.InferJSONContext(context.Background(), []byte(row))
+		require.NoError(t, err)
+
+		assert.Equal(t, want.Inference.clone(), got.Inference.clone(), row)
+	}
+
+	_, err := got.InferJSONContext(context.Background(), []byte(`{"a": 1} x`))
+	require.ErrorIs(t, err, errTrailingData)
+}
+
+func BenchmarkInferReader(b *testing.B) {
+	var sb strings.Builder
+
+	for _, rows := range benchmarkCorpora(10000) {
+		for _, row := range rows {
+			sb.WriteString(row)
+			sb.WriteByte('\n')
+		}
+	}
+
+	input := sb.String()
+
+	b.Run("decode", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(input)))
+
+		for n := 0; n < b.N; n++ {
+			decoder := json.NewDecoder(strings.NewReader(input))
+			decoder.UseNumber()
+
+			inferrer := NewInferrer(WithoutHints())
+
+			for {
+				var value any
+				if err := decoder.Decode(&value); errors.Is(err, io.EOF) {
+					break
+				}
+
+				inferrer = inferrer.Infer(value)
+			}
+		}
+	})
+
+	b.Run("tokens", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(input)))
+
+		for n := 0; n < b.N; n++ {
+			_, err := InferReader(strings.NewReader(input), ReaderOptions{}, WithoutHints())
+			require.NoError(b, err)
+		}
+	})
+}
@@ -0,0 +1,303 @@
+package jtdinfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAMLKeyError is returned when a YAML mapping has a key that isn't a string
+// since it can't be represented in JSON.
+type YAMLKeyError struct {
+	Line   int
+	Column int
+	Tag    string
+	Key    string
+}
+
+// Error implements the error interface.
+func (e *YAMLKeyError) Error() string {
+	return fmt.Sprintf(
+		"jtdinfer: non-string YAML key %q (%s) at line %d, column %d",
+		e.Key, e.Tag, e.Line, e.Column,
+	)
+}
+
+var (
+	errYAMLAliasCycle     = errors.New("alias refers to itself")
+	errYAMLAliasExpansion = errors.New("document contains excessive aliasing")
+)
+
+// InferYAML will decode all documents in the YAML stream and infer each
+// document as a separate value, the same way as each row in `InferStrings`.
+// See `InferYAMLContext` for details.
+func InferYAML(r io.Reader, hints Hints) (*Inferrer, error) {
+	return InferYAMLContext(context.Background(), r, hints)
+}
+
+// InferYAMLContext will decode all documents in the YAML stream and infer each
+// document as a separate value. The documents are decoded to nodes and
+// converted to `map[string]any` instead of the `map[any]any` YAML would decode
+// to so the keys can be validated. Aliases are expanded, merge keys (`<<`) are
+// merged and timestamps are decoded as `time.Time` which is inferred as a
+// timestamp. Documents where most nodes are expanded from aliases are rejected
+// the same way as when decoding with yaml.v3 to not expand exponentially large
+// documents. Mappings with keys that aren't strings returns a `*YAMLKeyError`
+// with the position of the key. If an error occurs the inferrer is returned
+// with the state it had after the last successfully inferred document.
+func InferYAMLContext(ctx context.Context, r io.Reader, hints Hints) (*Inferrer, error) {
+	inferrer := NewInferrer(hints)
+	decoder := yaml.NewDecoder(r)
+
+	for document := 0; ; document++ {
+		var node yaml.Node
+
+		err := decoder.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			return inferrer, nil
+		}
+
+		if err != nil {
+			return inferrer, fmt.Errorf("jtdinfer: invalid YAML in document %d: %w", document, err)
+		}
+
+		if len(node.Content) == 0 {
+			continue
+		}
+
+		value, err := newYAMLConverter().value(node.Content[0])
+		if err != nil {
+			return inferrer, fmt.Errorf("jtdinfer: invalid YAML in document %d: %w", document, err)
+		}
+
+		inferrer, err = inferrer.InferContext(ctx, value)
+		if err != nil {
+			return inferrer, err
+		}
+	}
+}
+
+// yamlConverter converts the nodes in a document. The value for each anchor
+// is converted once and shared by all aliases since values are never changed.
+type yamlConverter struct {
+	anchors map[*yaml.Node]yamlAnchor
+	// expanding holds the anchors currently being converted to detect cycles.
+	expanding map[*yaml.Node]struct{}
+	// nodes is the number of nodes in the document with aliases expanded and
+	// aliased is the number of nodes expanded from aliases.
+	nodes   int
+	aliased int
+}
+
+type yamlAnchor struct {
+	value any
+	nodes int
+}
+
+func newYAMLConverter() *yamlConverter {
+	return &yamlConverter{
+		anchors:   map[*yaml.Node]yamlAnchor{},
+		expanding: map[*yaml.Node]struct{}{},
+	}
+}
+
+// value converts the node to the same kind of values as JSON unmarshalled to
+// `any` except for timestamps that are kept as `time.Time`.
+func (c *yamlConverter) value(node *yaml.Node) (any, error) {
+	if node.Kind == yaml.AliasNode {
+		return c.alias(node)
+	}
+
+	if node.Anchor == "" {
+		return c.convert(node)
+	}
+
+	c.expanding[node] = struct{}{}
+	defer delete(c.expanding, node)
+
+	before := c.nodes
+
+	v, err := c.convert(node)
+	if err != nil {
+		return nil, err
+	}
+
+	c.anchors[node] = yamlAnchor{value: v, nodes: c.nodes - before}
+
+	return v, nil
+}
+
+// alias returns the value of the anchor the alias refers to and counts the
+// nodes it expands to.
+func (c *yamlConverter) alias(node *yaml.Node) (any, error) {
+	if _, ok := c.expanding[node.Alias]; ok {
+		return nil, fmt.Errorf("%w at line %d, column %d", errYAMLAliasCycle, node.Line, node.Column)
+	}
+
+	anchor, ok := c.anchors[node.Alias]
+	if !ok {
+		before := c.nodes
+
+		v, err := c.value(node.Alias)
+		if err != nil {
+			return nil, err
+		}
+
+		// The nodes are counted below as expanded from the alias instead.
+		c.nodes = before
+		anchor = yamlAnchor{value: v, nodes: c.anchors[node.Alias].nodes}
+	}
+
+	c.nodes += anchor.nodes
+	c.aliased += anchor.nodes
+
+	if c.aliased > 100 && c.nodes > 1000 && float64(c.aliased)/float64(c.nodes) > allowedYAMLAliasRatio(c.nodes) {
+		return nil, fmt.Errorf("%w at line %d, column %d", errYAMLAliasExpansion, node.Line, node.Column)
+	}
+
+	return anchor.value, nil
+}
+
+// allowedYAMLAliasRatio returns the ratio of nodes that may be expanded from
+// aliases for a document with the number of nodes, the same as yaml.v3 uses
+// when decoding to values.
+func allowedYAMLAliasRatio(nodes int) float64 {
+	switch {
+	case nodes <= 400_000:
+		return 0.99
+	case nodes >= 4_000_000:
+		return 0.10
+	}
+
+	return 0.99 - 0.89*(float64(nodes-400_000)/3_600_000)
+}
+
+// convert converts the node without handling anchors and aliases.
+func (c *yamlConverter) convert(node *yaml.Node) (any, error) {
+	c.nodes++
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+
+		return c.value(node.Content[0])
+	case yaml.SequenceNode:
+		values := make([]any, 0, len(node.Content))
+
+		for _, n := range node.Content {
+			v, err := c.value(n)
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, v)
+		}
+
+		return values, nil
+	case yaml.MappingNode:
+		m := make(map[string]any, len(node.Content)/2)
+		if err := c.mapping(node, m); err != nil {
+			return nil, err
+		}
+
+		return m, nil
+	case yaml.ScalarNode:
+		return yamlScalar(node)
+	}
+
+	return nil, fmt.Errorf("unsupported YAML node at line %d, column %d", node.Line, node.Column)
+}
+
+// yamlMapping adds all keys in the mapping node to the map. Keys from merged
+// mappings are added first so they're overridden by the keys in the mapping.
+func (c *yamlConverter) mapping(node *yaml.Node, m map[string]any) error {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Kind != yaml.ScalarNode || key.ShortTag() != "!!merge" {
+			continue
+		}
+
+		merged, err := c.value(value)
+		if err != nil {
+			return err
+		}
+
+		mergedMaps, ok := merged.([]any)
+		if !ok {
+			mergedMaps = []any{merged}
+		}
+
+		for _, mm := range mergedMaps {
+			mergedMap, ok := mm.(map[string]any)
+			if !ok {
+				return fmt.Errorf("merge value at line %d, column %d isn't a mapping", value.Line, value.Column)
+			}
+
+			for k, v := range mergedMap {
+				if _, ok := m[k]; !ok {
+					m[k] = v
+				}
+			}
+		}
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Kind == yaml.AliasNode {
+			key = key.Alias
+		}
+
+		if key.Kind == yaml.ScalarNode && key.ShortTag() == "!!merge" {
+			continue
+		}
+
+		if key.Kind != yaml.ScalarNode || key.ShortTag() != "!!str" {
+			return &YAMLKeyError{
+				Line:   node.Content[i].Line,
+				Column: node.Content[i].Column,
+				Tag:    key.ShortTag(),
+				Key:    key.Value,
+			}
+		}
+
+		v, err := c.value(value)
+		if err != nil {
+			return err
+		}
+
+		m[key.Value] = v
+	}
+
+	return nil
+}
+
+// yamlScalar decodes the scalar based on its tag. Scalars with custom tags such
+// as `!Ref` are kept as strings.
+func yamlScalar(node *yaml.Node) (any, error) {
+	switch node.ShortTag() {
+	case "!!null":
+		return nil, nil
+	case "!!bool", "!!int", "!!float":
+		var v any
+		if err := node.Decode(&v); err != nil {
+			return nil, err
+		}
+
+		return v, nil
+	case "!!timestamp":
+		var t time.Time
+		if err := node.Decode(&t); err != nil {
+			return nil, err
+		}
+
+		return t, nil
+	}
+
+	return node.Value, nil
+}
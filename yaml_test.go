@@ -0,0 +1,165 @@
+package jtdinfer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	jtd "github.com/jsontypedef/json-typedef-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInferYAML(t *testing.T) {
+	input := `
+defaults: &defaults
+  image: nginx
+  replicas: 1
+---
+name: web
+created: 2001-12-14
+spec:
+  <<: *defaults
+  replicas: 3
+ports:
+  - 80
+  - 443
+ref: !Ref Bucket
+---
+name: api
+created: 2023-01-01T10:00:00Z
+spec:
+  <<: *other
+  image: api
+ports: []
+ref: arn
+`
+
+	// Anchors are only valid within a document so the last document refers
+	// to an unknown anchor.
+	_, err := InferYAML(strings.NewReader(input), WithoutHints())
+	require.Error(t, err)
+
+	input = strings.Replace(input, "*other", "{image: x, replicas: 2}", 1)
+
+	inferrer, err := InferYAML(strings.NewReader(input), WithoutHints())
+	require.NoError(t, err)
+
+	assert.Equal(t, Schema{
+		Properties: map[string]Schema{},
+		OptionalProperties: map[string]Schema{
+			"defaults": {
+				Properties: map[string]Schema{
+					"image":    {Type: jtd.TypeString},
+					"replicas": {Type: jtd.TypeUint8},
+				},
+			},
+			"name":    {Type: jtd.TypeString},
+			"created": {Type: jtd.TypeTimestamp},
+			"spec": {
+				Properties: map[string]Schema{
+					"image":    {Type: jtd.TypeString},
+					"replicas": {Type: jtd.TypeUint8},
+				},
+			},
+			"ports": {Elements: &Schema{Type: jtd.TypeUint16}},
+			"ref":   {Type: jtd.TypeString},
+		},
+	}, inferrer.IntoSchema())
+}
+
+func TestInferYAMLErrors(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		input       string
+		err         string
+	}{
+		{
+			description: "integer key",
+			input:       "a: 1\nb:\n  1: x\n",
+			err:         `jtdinfer: non-string YAML key "1" (!!int) at line 3, column 3`,
+		},
+		{
+			description: "mapping key",
+			input:       "? [a]\n: 1\n",
+			err:         `jtdinfer: non-string YAML key "" (!!seq) at line 1, column 3`,
+		},
+		{
+			description: "alias cycle",
+			input:       "a: &x [1, *x]\n",
+			err:         "jtdinfer: invalid YAML in document 0: alias refers to itself at line 1, column 11",
+		},
+		{
+			description: "excessive aliasing",
+			input:       billionLaughs(),
+			err:         "document contains excessive aliasing",
+		},
+		{
+			description: "invalid yaml",
+			input:       "a: 1\n---\na: [\n",
+			err:         "jtdinfer: invalid YAML in document 1",
+		},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			_, err := InferYAML(strings.NewReader(tc.input), WithoutHints())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
+
+// billionLaughs returns a document that expands to 10^9 values.
+func billionLaughs() string {
+	var sb strings.Builder
+
+	sb.WriteString("a: &a [x, x, x, x, x, x, x, x, x, x]\n")
+
+	for c := 'b'; c <= 'i'; c++ {
+		fmt.Fprintf(&sb, "%c: &%c [", c, c)
+
+		for i := 0; i < 10; i++ {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+
+			fmt.Fprintf(&sb, "*%c", c-1)
+		}
+
+		sb.WriteString("]\n")
+	}
+
+	return sb.String()
+}
+
+func TestInferYAMLAliases(t *testing.T) {
+	inferrer, err := InferYAML(strings.NewReader("a: &a {b: &b [1, 2]}\nc: *a\nd: [*b, *b]\n"), WithoutHints())
+	require.NoError(t, err)
+
+	elements := &Schema{Elements: &Schema{Type: jtd.TypeUint8}}
+
+	assert.Equal(t, Schema{
+		Properties: map[string]Schema{
+			"a": {Properties: map[string]Schema{"b": *elements}},
+			"c": {Properties: map[string]Schema{"b": *elements}},
+			"d": {Elements: elements},
+		},
+	}, inferrer.IntoSchema())
+}
+
+func TestInferYAMLKeyError(t *testing.T) {
+	_, err := InferYAML(strings.NewReader("true: x\n"), WithoutHints())
+
+	var keyErr *YAMLKeyError
+	require.ErrorAs(t, err, &keyErr)
+	assert.Equal(t, 1, keyErr.Line)
+	assert.Equal(t, "!!bool", keyErr.Tag)
+}
+
+func TestInferTime(t *testing.T) {
+	schema := NewInferrer(WithoutHints()).
+		Infer(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)).
+		IntoSchema()
+
+	assert.Equal(t, Schema{Type: jtd.TypeTimestamp}, schema)
+}